@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"stone-tools/lib"
+	"stone-tools/lib/tga"
+	"strings"
+)
+
+func main() {
+	inputPath := flag.String("in", "", "path to the .O3D model to export")
+	outputDirectory := flag.String("out", "out", "directory to write the exported model into")
+	texturePaths := flag.String("textures", "", "directories to search for K####*.TGA textures, separated by "+string(os.PathListSeparator)+", earlier ones win")
+	bankPriority := flag.String("banks", "", "comma separated texture banks to prefer, e.g. DRAGONBLADE")
+	preferLowRes := flag.Bool("lowres", false, "prefer the low resolution R#### textures")
+	format := flag.String("format", "obj", "export format, either obj or glb")
+	flag.Parse()
+
+	if *inputPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	textureResolver := lib.NewTextureResolver(filepath.SplitList(*texturePaths)...)
+	textureResolver.PreferLowRes = *preferLowRes
+	if *bankPriority != "" {
+		textureResolver.BankPriority = strings.Split(*bankPriority, ",")
+	}
+
+	var err error
+	switch *format {
+	case "obj":
+		err = exportObj(*inputPath, *outputDirectory, textureResolver)
+	case "glb":
+		err = exportGlb(*inputPath, *outputDirectory, textureResolver)
+	default:
+		err = fmt.Errorf("unknown export format `%s`", *format)
+	}
+	if err != nil {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadModel(inputPath, outputDirectory string) (lib.O3DModel, error) {
+	o3dFile, err := os.Open(inputPath)
+	if err != nil {
+		return lib.O3DModel{}, err
+	}
+
+	o3dModel, err := lib.ExtractO3D(o3dFile)
+	o3dFile.Close()
+	if err != nil {
+		return lib.O3DModel{}, err
+	}
+
+	return o3dModel, os.MkdirAll(outputDirectory, os.ModePerm)
+}
+
+func exportObj(inputPath, outputDirectory string, textureResolver *lib.TextureResolver) error {
+	o3dModel, err := loadModel(inputPath, outputDirectory)
+	if err != nil {
+		return err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	textureFileNames := convertTextures(o3dModel, textureResolver, outputDirectory)
+
+	mtlFileName := baseName + ".mtl"
+	mtlFile, err := os.Create(filepath.Join(outputDirectory, mtlFileName))
+	if err != nil {
+		return err
+	}
+	defer mtlFile.Close()
+
+	err = lib.WriteMtl(mtlFile, o3dModel, textureFileNames)
+	if err != nil {
+		return err
+	}
+
+	objFile, err := os.Create(filepath.Join(outputDirectory, baseName+".obj"))
+	if err != nil {
+		return err
+	}
+	defer objFile.Close()
+
+	return lib.WriteObj(objFile, o3dModel, mtlFileName)
+}
+
+func exportGlb(inputPath, outputDirectory string, textureResolver *lib.TextureResolver) error {
+	o3dModel, err := loadModel(inputPath, outputDirectory)
+	if err != nil {
+		return err
+	}
+
+	textures := make(map[uint16]image.Image)
+	for materialId, texturePath := range resolveTextures(o3dModel, textureResolver) {
+		texture, err := loadTga(texturePath)
+		if err != nil {
+			fmt.Printf("could not load texture `%s`: %v\n", texturePath, err)
+			continue
+		}
+
+		textures[materialId] = texture
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	glbFile, err := os.Create(filepath.Join(outputDirectory, baseName+".glb"))
+	if err != nil {
+		return err
+	}
+	defer glbFile.Close()
+
+	return lib.WriteGlb(glbFile, o3dModel, baseName, textures)
+}
+
+func resolveTextures(o3dModel lib.O3DModel, textureResolver *lib.TextureResolver) map[uint16]string {
+	texturePaths, unresolved := textureResolver.ResolveModel(o3dModel)
+	for _, materialId := range unresolved {
+		fmt.Printf("could not find a texture for material %d\n", materialId)
+	}
+
+	return texturePaths
+}
+
+// converts every texture the model references into a png next to the exported model, missing textures are reported and skipped
+func convertTextures(o3dModel lib.O3DModel, textureResolver *lib.TextureResolver, outputDirectory string) map[uint16]string {
+	textureFileNames := make(map[uint16]string)
+	for materialId, texturePath := range resolveTextures(o3dModel, textureResolver) {
+		pngFileName := lib.ObjMaterialName(materialId) + ".png"
+		err := convertTgaToPng(texturePath, filepath.Join(outputDirectory, pngFileName))
+		if err != nil {
+			fmt.Printf("could not convert texture `%s`: %v\n", texturePath, err)
+			continue
+		}
+
+		textureFileNames[materialId] = pngFileName
+	}
+
+	return textureFileNames
+}
+
+func loadTga(tgaPath string) (image.Image, error) {
+	tgaFile, err := os.Open(tgaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer tgaFile.Close()
+
+	return tga.Decode(tgaFile)
+}
+
+func convertTgaToPng(tgaPath, pngPath string) error {
+	tgaImage, err := loadTga(tgaPath)
+	if err != nil {
+		return err
+	}
+
+	pngFile, err := os.Create(pngPath)
+	if err != nil {
+		return err
+	}
+	defer pngFile.Close()
+
+	return png.Encode(pngFile, tgaImage)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stone-tools/lib"
+	"strings"
+)
+
+func main() {
+	inputPath := flag.String("in", "", "path to the .obj mesh to import")
+	outputPath := flag.String("out", "", "path of the .O3D file to write, defaults to the input name next to it")
+	materials := flag.String("materials", "", "usemtl names to material ids as name=id pairs separated by commas, e.g. wood=K0012,stone=40")
+	autoFrom := flag.Uint("auto-from", 9000, "first material id handed out to usemtl names that are neither K#### nor mapped with -materials")
+	flag.Parse()
+
+	if *inputPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *autoFrom > uint(lib.O3DUnused) {
+		fmt.Printf("An Error Occurred: -auto-from must be at most %d\n", lib.O3DUnused)
+		os.Exit(2)
+	}
+
+	if *outputPath == "" {
+		*outputPath = strings.TrimSuffix(*inputPath, filepath.Ext(*inputPath)) + ".O3D"
+	}
+
+	materialIds, err := parseMaterials(*materials)
+	if err != nil {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(2)
+	}
+
+	err = importObj(*inputPath, *outputPath, materialIds, uint16(*autoFrom))
+	if err != nil {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// name=id pairs, the id written either as K#### or as a plain number
+func parseMaterials(value string) (map[string]uint16, error) {
+	materialIds := make(map[string]uint16)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, id, found := strings.Cut(pair, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("material mapping `%s` is not name=id", pair)
+		}
+		materialId, ok := lib.ObjMaterialId(id)
+		if !ok {
+			return nil, fmt.Errorf("material mapping `%s` has no valid id", pair)
+		}
+		materialIds[name] = materialId
+	}
+
+	return materialIds, nil
+}
+
+func importObj(inputPath, outputPath string, materialIds map[string]uint16, autoFrom uint16) error {
+	objData, err := os.ReadFile(inputPath)
+	if err != nil {
+		return err
+	}
+
+	names, err := lib.ObjMaterialNames(bytes.NewReader(objData))
+	if err != nil {
+		return err
+	}
+	assigned, err := assignMaterialIds(names, materialIds, autoFrom)
+	if err != nil {
+		return err
+	}
+	printMaterialTable(names, materialIds, assigned)
+
+	o3dModel, err := lib.ReadObj(bytes.NewReader(objData), materialIds)
+	if err != nil {
+		return err
+	}
+
+	o3dFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer o3dFile.Close()
+
+	err = lib.WriteO3D(o3dFile, o3dModel)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote `%s` (%d vertices, %d faces)\n", outputPath, o3dModel.NumberOfVertices, o3dModel.NumberOfFaces)
+	return nil
+}
+
+// gives every name ReadObj could not map on its own the next id from autoFrom that nothing else uses,
+// adding them to materialIds and returning which ones it made up
+func assignMaterialIds(names []string, materialIds map[string]uint16, autoFrom uint16) (map[string]bool, error) {
+	taken := make(map[uint16]bool)
+	for _, materialId := range materialIds {
+		taken[materialId] = true
+	}
+	for _, name := range names {
+		if materialId, ok := lib.ObjMaterialId(name); ok {
+			taken[materialId] = true
+		}
+	}
+
+	assigned := make(map[string]bool)
+	next := uint32(autoFrom)
+	for _, name := range names {
+		if _, ok := materialIds[name]; ok {
+			continue
+		}
+		if _, ok := lib.ObjMaterialId(name); ok {
+			continue
+		}
+
+		for next < uint32(lib.O3DUnused) && taken[uint16(next)] {
+			next++
+		}
+		if next >= uint32(lib.O3DUnused) {
+			return nil, fmt.Errorf("ran out of material ids for `%s`, lower -auto-from", name)
+		}
+
+		materialIds[name] = uint16(next)
+		taken[uint16(next)] = true
+		assigned[name] = true
+	}
+
+	return assigned, nil
+}
+
+func printMaterialTable(names []string, materialIds map[string]uint16, assigned map[string]bool) {
+	if len(names) == 0 {
+		return
+	}
+
+	fmt.Println("Materials:")
+	for _, name := range names {
+		materialId, ok := materialIds[name]
+		source := "mapped"
+		if !ok {
+			materialId, _ = lib.ObjMaterialId(name)
+			source = "from name"
+		}
+		if assigned[name] {
+			source = "assigned, name the texture " + lib.ObjMaterialName(materialId) + ".TGA"
+		}
+		fmt.Printf("  %-20s %s (%s)\n", name, lib.ObjMaterialName(materialId), source)
+	}
+}
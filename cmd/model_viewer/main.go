@@ -2,13 +2,13 @@ package main
 
 import (
 	"fmt"
-	"image/png"
 	"os"
 	"path/filepath"
+	"stone-tools/config"
 	"stone-tools/lib"
+	"stone-tools/lib/tga"
 	"unsafe"
 
-	tga "github.com/davehouse/go-targa"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -34,10 +34,7 @@ const (
 )
 
 func loadTexture(texturePath string) (rl.Texture2D, error) {
-	/*
-		As of today (2025-03-16), the raylib-go tga parser does not seem to fully appreaciate the targa files packed with Darkstone,
-			as such, this is a nasty worky around to load the TGA with a different library then re-save it as a png
-	*/
+	// raylib's own tga parser does not appreciate the targa files packed with Darkstone, so decode them ourselves
 	tgaFile, err := os.Open(texturePath)
 	if err != nil {
 		return rl.Texture2D{}, err
@@ -49,95 +46,32 @@ func loadTexture(texturePath string) (rl.Texture2D, error) {
 		return rl.Texture2D{}, err
 	}
 
-	pngFile, err := os.CreateTemp(".", "*.png")
-	if err != nil {
-		return rl.Texture2D{}, err
-	}
-	defer func() {
-		// close and clean-up temp file
-		pngFile.Close()
-		os.Remove(pngFile.Name())
-	}()
-
-	err = png.Encode(pngFile, tgaImage)
-	if err != nil {
-		pngFile.Close()
-		return rl.Texture2D{}, err
-	}
+	image := rl.NewImageFromImage(tgaImage)
+	defer rl.UnloadImage(image)
 
-	texture := rl.LoadTexture(pngFile.Name())
+	texture := rl.LoadTextureFromImage(image)
 	return texture, nil
 }
 
-func main() {
-	// Initialization
-	//--------------------------------------------------------------------------------------
-	const screenWidth = 800
-	const screenHeight = 450
-
-	// TODO don't hardcode this...
-	// o3dFile, err := os.Open(filepath.Join("..", "..", "out", "data", "DATA", "PROJECTILE", "DAGUE2.O3D"))
-	o3dFile, err := os.Open(filepath.Join("..", "..", "out", "data", "DATA", "COMMON", "MESHES", "TORCHE.O3D"))
-	if err != nil {
-		panic(err)
-	}
-
-	o3dModel, err := lib.ExtractO3D(o3dFile)
-	o3dFile.Close() // ensure closed NOW
-	if err != nil {
-		panic(err)
-	}
-	rl.InitWindow(screenWidth, screenHeight, "Stone Model Viewer")
-
-	// TODO supports mod directories, multi-texture models, and lower-res R textures
-	textureSearchPath := filepath.Join("..", "..", "out", "data", "DATA", "BANKDATABASE", "DRAGONBLADE", fmt.Sprintf("K%04d*.TGA", o3dModel.Faces[0].MaterialId))
-	files, err := filepath.Glob(textureSearchPath)
-	if err != nil {
-		panic(err)
-	} else if len(files) <= 0 {
-		panic("could not find a texture file...")
-	}
-
-	// texture := rl.LoadTexture(files[0])
-	texture, err := loadTexture(files[0])
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer rl.UnloadTexture(texture)
-
-	// Define the camera to look into our 3d world
-	var distance float32 = 60
-	var yaw float32 = 300.0
-	var pitch float32 = 34.0
-	var graphicsMode GraphicsMode = GraphicsModeWireFrame
-	wantsToChangeMode := false
-	var isGridOn = true
-	wantsToChangeGrid := false
+type materialMesh struct {
+	// keep the backing arrays referenced for as long as raylib points at them
+	vertices  []float32
+	texcoords []float32
+	colors    []uint8
 
-	var camera rl.Camera
-	camera.Position = rl.Vector3{
-		X: 0.0,
-		Y: 10.0,
-		Z: 10.0,
-	}
-	camera.Target = rl.Vector3{
-		X: 0.0,
-		Y: 0.0,
-		Z: 0.0,
-	}
-	camera.Up = rl.Vector3{
-		X: 0.0,
-		Y: 1.0,
-		Z: 0.0,
-	}
-	camera.Fovy = 45.0
-	camera.Projection = rl.CameraPerspective
+	mesh  rl.Mesh
+	model rl.Model
+}
 
+func buildMaterialMesh(o3dModel lib.O3DModel, materialId uint16) materialMesh {
 	vertices := make([]float32, 0)
 	texcoords := make([]float32, 0)
 	colors := make([]uint8, 0)
 	for _, face := range o3dModel.Faces {
+		if face.MaterialId != materialId {
+			continue
+		}
+
 		vertex0 := o3dModel.Vertices[face.V0]
 		vertex1 := o3dModel.Vertices[face.V1]
 		vertex2 := o3dModel.Vertices[face.V2]
@@ -191,13 +125,105 @@ func main() {
 	mesh.Texcoords = (*float32)(unsafe.Pointer(&texcoords[0]))
 	mesh.Colors = (*uint8)(unsafe.Pointer(&colors[0]))
 
-	rl.UploadMesh(&mesh, false)
-	theModel := rl.LoadModelFromMesh(mesh)
-	if texture.ID > 0 {
-		// only apply texture if has a valid id
-		theModel.Materials.Maps.Texture = texture
+	return materialMesh{
+		vertices:  vertices,
+		texcoords: texcoords,
+		colors:    colors,
+		mesh:      mesh,
+	}
+}
+
+func main() {
+	// Initialization
+	//--------------------------------------------------------------------------------------
+	const screenWidth = 800
+	const screenHeight = 450
+
+	// TODO don't hardcode this...
+	// o3dFile, err := os.Open(filepath.Join("..", "..", "out", "data", "DATA", "PROJECTILE", "DAGUE2.O3D"))
+	o3dFile, err := os.Open(filepath.Join("..", "..", "out", "data", "DATA", "COMMON", "MESHES", "TORCHE.O3D"))
+	if err != nil {
+		panic(err)
+	}
+
+	o3dModel, err := lib.ExtractO3D(o3dFile)
+	o3dFile.Close() // ensure closed NOW
+	if err != nil {
+		panic(err)
+	}
+
+	issues := lib.ValidateO3D(o3dModel, nil)
+	if lib.HasO3DErrors(issues) {
+		fmt.Println("model is not valid:")
+		for _, issue := range issues {
+			if issue.Severity == lib.O3DSeverityError {
+				fmt.Printf("  - %s: %s\n", issue.Severity, issue.Message)
+			}
+		}
+		os.Exit(1)
+	}
+	rl.InitWindow(screenWidth, screenHeight, "Stone Model Viewer")
+
+	conf, err := config.LoadConfig()
+	if err != nil {
+		panic(err)
+	}
+	textureResolver := conf.TextureResolver(conf.Viewer.TexturePaths...)
+
+	texturePaths, unresolved := textureResolver.ResolveModel(o3dModel)
+	for _, materialId := range unresolved {
+		fmt.Printf("could not find a texture for material %d\n", materialId)
+	}
+
+	// Define the camera to look into our 3d world
+	var distance float32 = 60
+	var yaw float32 = 300.0
+	var pitch float32 = 34.0
+	var graphicsMode GraphicsMode = GraphicsModeWireFrame
+	wantsToChangeMode := false
+	var isGridOn = true
+	wantsToChangeGrid := false
+
+	var camera rl.Camera
+	camera.Position = rl.Vector3{
+		X: 0.0,
+		Y: 10.0,
+		Z: 10.0,
+	}
+	camera.Target = rl.Vector3{
+		X: 0.0,
+		Y: 0.0,
+		Z: 0.0,
+	}
+	camera.Up = rl.Vector3{
+		X: 0.0,
+		Y: 1.0,
+		Z: 0.0,
+	}
+	camera.Fovy = 45.0
+	camera.Projection = rl.CameraPerspective
+
+	// one model per material since each can only carry a single texture
+	materialMeshes := make([]materialMesh, 0)
+	for _, materialId := range lib.O3DMaterialIds(o3dModel) {
+		current := buildMaterialMesh(o3dModel, materialId)
+		rl.UploadMesh(&current.mesh, false)
+		current.model = rl.LoadModelFromMesh(current.mesh)
+
+		if texturePath, ok := texturePaths[materialId]; ok {
+			texture, err := loadTexture(texturePath)
+			if err != nil {
+				fmt.Println(err)
+			} else if texture.ID > 0 {
+				// only apply texture if has a valid id
+				current.model.Materials.Maps.Texture = texture
+				defer rl.UnloadTexture(texture)
+			}
+		}
+		defer rl.UnloadModel(current.model)
+
+		materialMeshes = append(materialMeshes, current)
 	}
-	defer rl.UnloadModel(theModel)
 
 	// run at 60 fps, close down window in finish
 	rl.SetTargetFPS(60)
@@ -285,10 +311,12 @@ func main() {
 
 		rl.BeginMode3D(camera)
 
-		if graphicsMode == GraphicsModeWireFrame {
-			rl.DrawModelWires(theModel, rl.Vector3Zero(), 1.0, rl.White)
-		} else {
-			rl.DrawModel(theModel, rl.Vector3Zero(), 1.0, rl.White)
+		for _, current := range materialMeshes {
+			if graphicsMode == GraphicsModeWireFrame {
+				rl.DrawModelWires(current.model, rl.Vector3Zero(), 1.0, rl.White)
+			} else {
+				rl.DrawModel(current.model, rl.Vector3Zero(), 1.0, rl.White)
+			}
 		}
 
 		if isGridOn {
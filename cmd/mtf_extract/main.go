@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stone-tools/config"
+	"stone-tools/lib"
+	"strings"
+)
+
+func main() {
+	// the saved settings only provide the defaults, flags still win
+	conf, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(1)
+	}
+	defaultOptions := conf.ConvertOptions()
+
+	outputDirectory := flag.String("out", conf.Extraction.OutputDirectory, "directory to extract into, each archive gets its own folder inside")
+	replace := flag.Bool("replace", defaultOptions.Replace, "write converted files instead of the raw ones rather than next to them")
+	overwrite := flag.String("overwrite", string(defaultOptions.Overwrite), fmt.Sprintf("what to do with files that already exist, one of %v", lib.OverwritePolicies))
+	list := flag.Bool("list", false, "list the archives' contents instead of extracting them")
+	converterFlags := make(map[string]*bool)
+	for _, converter := range lib.Converters {
+		enabled := false
+		for _, defaultConverter := range defaultOptions.Converters {
+			enabled = enabled || defaultConverter.Name == converter.Name
+		}
+		converterFlags[converter.Name] = flag.Bool(converter.Name, enabled, "convert "+converter.Description+" while extracting")
+	}
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <archive.mtf|disc.iso|disc.iso!/path/archive.mtf>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conf.Extraction.Overwrite = lib.OverwritePolicy(*overwrite)
+	err = conf.Validate()
+	if err != nil {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(1)
+	}
+
+	convertOptions := lib.ConvertOptions{Replace: *replace, Overwrite: conf.Extraction.Overwrite}
+	for _, converter := range lib.Converters {
+		if *converterFlags[converter.Name] {
+			convertOptions.Converters = append(convertOptions.Converters, converter)
+		}
+	}
+
+	for _, mtfFilePath := range archivePaths(flag.Args()) {
+		if *list {
+			err := listArchive(mtfFilePath)
+			if err != nil {
+				fmt.Printf("Error listing `%s`: %v\n", mtfFilePath, err)
+			}
+			continue
+		}
+
+		archiveDirectory := filepath.Join(*outputDirectory, strings.TrimSuffix(filepath.Base(mtfFilePath), filepath.Ext(mtfFilePath)))
+		lib.ExtractAllFiles(mtfFilePath, archiveDirectory, convertOptions)
+	}
+}
+
+// a bare iso stands for every archive on the disc
+func archivePaths(args []string) []string {
+	archivePaths := make([]string, 0, len(args))
+	for _, arg := range args {
+		if _, _, inIso := lib.SplitArchivePath(arg); inIso || !strings.EqualFold(filepath.Ext(arg), ".iso") {
+			archivePaths = append(archivePaths, arg)
+			continue
+		}
+
+		isoArchivePaths, err := lib.ListIsoArchives(arg)
+		if err != nil {
+			fmt.Printf("Error reading `%s`: %v\n", arg, err)
+			continue
+		}
+		archivePaths = append(archivePaths, isoArchivePaths...)
+	}
+
+	return archivePaths
+}
+
+func listArchive(mtfFilePath string) error {
+	mtfFile, closer, err := lib.OpenArchiveFile(mtfFilePath)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	archive, err := lib.ScanMtfFile(mtfFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(mtfFilePath)
+	for _, virtualFile := range archive.VirtualFiles {
+		fmt.Printf("  %10d  %s\n", virtualFile.TotalSize, virtualFile.FileName)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"stone-tools/lib"
+	"strings"
+)
+
+func main() {
+	regex := flag.Bool("regex", false, "treat the pattern as a regular expression")
+	hexBytes := flag.Bool("hex", false, "treat the pattern as hex bytes, e.g. `de ad be ef`")
+	ignoreCase := flag.Bool("i", false, "ignore ascii case")
+	archiveFilter := flag.String("archive", "", "comma separated archive name globs to search, e.g. DATA*.MTF")
+	extensionFilter := flag.String("ext", "", "comma separated extensions to search, e.g. txt,ini")
+	workers := flag.Int("workers", 0, "entries decompressed at once, defaults to the number of cpus")
+	contextBytes := flag.Int("context", 24, "bytes of context shown either side of a match")
+	maxMatches := flag.Int("max", 0, "stop after this many matches per entry, 0 for all")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <pattern> <install directory|archive.mtf|disc.iso>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 || (*regex && *hexBytes) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	options := lib.GrepOptions{
+		Mode:               lib.GrepLiteral,
+		Pattern:            flag.Arg(0),
+		IgnoreCase:         *ignoreCase,
+		ArchiveFilters:     splitList(*archiveFilter),
+		Extensions:         splitList(*extensionFilter),
+		Workers:            *workers,
+		ContextBytes:       *contextBytes,
+		MaxMatchesPerEntry: *maxMatches,
+	}
+	if *regex {
+		options.Mode = lib.GrepRegex
+	}
+	if *hexBytes {
+		options.Mode = lib.GrepHex
+	}
+
+	// ctrl+c stops the workers but still reports what was found
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	matchCount := 0
+	stats, err := lib.Grep(ctx, archivePaths(flag.Args()[1:]), options, func(match lib.GrepMatch) {
+		matchCount++
+		before, matched, after := match.SnippetText()
+		if options.Mode == lib.GrepHex {
+			before, matched, after = match.SnippetHex()
+		}
+		fmt.Printf("%s%s%s:0x%x: %s[%s]%s\n", match.ArchivePath, lib.IsoPathSeparator, filepath.ToSlash(match.FileName), match.Offset, before, matched, after)
+	})
+	for _, entryErr := range stats.Errors {
+		fmt.Printf("Error: %v\n", entryErr)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d matches in %d entries (%d bytes) across %d archives\n", matchCount, stats.Entries, stats.Bytes, stats.Archives)
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("Search was canceled.")
+		os.Exit(130)
+	}
+	if matchCount == 0 {
+		os.Exit(1)
+	}
+}
+
+// directories stand for every archive below them, a bare iso for every archive on the disc
+func archivePaths(args []string) []string {
+	archivePaths := make([]string, 0, len(args))
+	for _, arg := range args {
+		if _, _, inIso := lib.SplitArchivePath(arg); inIso {
+			archivePaths = append(archivePaths, arg)
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			fmt.Printf("Error reading `%s`: %v\n", arg, err)
+			continue
+		}
+		if info.IsDir() || strings.EqualFold(filepath.Ext(arg), ".iso") {
+			archivePaths = append(archivePaths, expand(arg, info.IsDir())...)
+			continue
+		}
+		archivePaths = append(archivePaths, arg)
+	}
+
+	return archivePaths
+}
+
+func expand(path string, isDir bool) []string {
+	if isDir {
+		return lib.FindArchives(path)
+	}
+
+	isoArchivePaths, err := lib.ListIsoArchives(path)
+	if err != nil {
+		fmt.Printf("Error reading `%s`: %v\n", path, err)
+	}
+	return isoArchivePaths
+}
+
+func splitList(value string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"stone-tools/lib"
+	"strings"
+)
+
+type lintResult struct {
+	File   string         `json:"file"`
+	Error  string         `json:"error,omitempty"`
+	Issues []lib.O3DIssue `json:"issues"`
+}
+
+func main() {
+	texturePaths := flag.String("textures", "", "directories to search for K####*.TGA textures separated by "+string(os.PathListSeparator)+", enables the missing texture check")
+	jsonOutput := flag.Bool("json", false, "print results as json for batch runs")
+	showInfo := flag.Bool("info", false, "also report informational issues like unused vertices")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <model.O3D|directory>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var textureResolver *lib.TextureResolver
+	if *texturePaths != "" {
+		textureResolver = lib.NewTextureResolver(filepath.SplitList(*texturePaths)...)
+	}
+
+	results := make([]lintResult, 0)
+	for _, path := range flag.Args() {
+		err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".o3d") {
+				results = append(results, lintFile(path, textureResolver, *showInfo))
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("An Error Occurred: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	hasErrors := false
+	for _, result := range results {
+		if result.Error != "" || lib.HasO3DErrors(result.Issues) {
+			hasErrors = true
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(results)
+	} else {
+		printResults(results)
+	}
+
+	if hasErrors {
+		os.Exit(1)
+	}
+}
+
+func lintFile(path string, textureResolver *lib.TextureResolver, showInfo bool) lintResult {
+	result := lintResult{File: path, Issues: make([]lib.O3DIssue, 0)}
+
+	o3dFile, err := os.Open(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// the header counts decide how much is read, a wrong count either runs out of file or leaves bytes behind
+	o3dModel, err := lib.ExtractO3D(o3dFile)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		o3dFile.Close()
+		result.Error = "file is shorter than its header's vertex and face counts"
+		return result
+	}
+	if err != nil {
+		o3dFile.Close()
+		result.Error = err.Error()
+		return result
+	}
+
+	trailing, err := io.Copy(io.Discard, o3dFile)
+	o3dFile.Close()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if trailing > 0 {
+		result.Issues = append(result.Issues, lib.O3DIssue{
+			Severity:   lib.O3DSeverityError,
+			Kind:       lib.O3DIssueTrailingData,
+			Face:       -1,
+			Vertex:     -1,
+			MaterialId: -1,
+			Message:    fmt.Sprintf("%d bytes follow the last face, the header counts are probably wrong", trailing),
+		})
+	}
+
+	var hasTexture func(uint16) bool
+	if textureResolver != nil {
+		hasTexture = func(materialId uint16) bool {
+			_, ok := textureResolver.Resolve(materialId)
+			return ok
+		}
+	}
+
+	for _, issue := range lib.ValidateO3D(o3dModel, hasTexture) {
+		if issue.Severity == lib.O3DSeverityInfo && !showInfo {
+			continue
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+
+	return result
+}
+
+func printResults(results []lintResult) {
+	problemFiles := 0
+	for _, result := range results {
+		if result.Error == "" && len(result.Issues) == 0 {
+			continue
+		}
+
+		problemFiles++
+		fmt.Println(result.File)
+		if result.Error != "" {
+			fmt.Printf("  - error: %s\n", result.Error)
+		}
+		for _, issue := range result.Issues {
+			fmt.Printf("  - %s: %s\n", issue.Severity, issue.Message)
+		}
+	}
+
+	fmt.Printf("Checked %d models, %d with problems.\n", len(results), problemFiles)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"image"
+)
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+)
+
+// just enough of a 5x7 bitmap font to label textures, every row is 5 bits with the leftmost pixel highest
+var glyphs = map[rune][glyphHeight]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'?': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+}
+
+func drawLabel(destination *image.NRGBA, x, y int, label string) {
+	for _, character := range label {
+		glyph, ok := glyphs[character]
+		if ok {
+			for row := range glyphHeight {
+				for column := range glyphWidth {
+					if glyph[row]&(0x10>>column) == 0 {
+						continue
+					}
+
+					for dy := range glyphScale {
+						for dx := range glyphScale {
+							destination.SetNRGBA(x+column*glyphScale+dx, y+row*glyphScale+dy, labelColor)
+						}
+					}
+				}
+			}
+		}
+
+		x += (glyphWidth + 1) * glyphScale
+	}
+}
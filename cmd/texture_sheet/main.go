@@ -0,0 +1,241 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"stone-tools/lib"
+	"stone-tools/lib/tga"
+	"strings"
+)
+
+const (
+	labelHeight = 20
+	tilePadding = 4
+	glyphScale  = 2
+)
+
+var backgroundColor = color.NRGBA{R: 32, G: 32, B: 32, A: 255}
+var labelColor = color.NRGBA{R: 255, G: 255, B: 0, A: 255}
+
+func main() {
+	mode := flag.String("mode", "sheet", "either sheet, for labeled contact sheets, or atlas, to pack one model's textures")
+	texturePaths := flag.String("textures", "", "directories to search for K####*.TGA textures separated by "+string(os.PathListSeparator)+", e.g. a single bank")
+	modelPath := flag.String("model", "", "only use the textures this .O3D references, required for atlas mode")
+	outputDirectory := flag.String("out", "out", "directory to write the sheets or atlas into")
+	tileSize := flag.Int("tile", 128, "size of each contact sheet tile in pixels")
+	columns := flag.Int("columns", 8, "tiles per contact sheet row")
+	rows := flag.Int("rows", 8, "tile rows per contact sheet before starting a new sheet")
+	flag.Parse()
+
+	if *texturePaths == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *tileSize < 1 || *columns < 1 || *rows < 1 {
+		fmt.Println("-tile, -columns and -rows must be at least 1")
+		os.Exit(2)
+	}
+
+	textureResolver := lib.NewTextureResolver(filepath.SplitList(*texturePaths)...)
+	err := os.MkdirAll(*outputDirectory, os.ModePerm)
+	if err == nil {
+		switch *mode {
+		case "sheet":
+			err = writeContactSheets(textureResolver, *modelPath, *outputDirectory, *tileSize, *columns, *rows)
+		case "atlas":
+			err = writeAtlas(textureResolver, *modelPath, *outputDirectory)
+		default:
+			err = fmt.Errorf("unknown mode `%s`", *mode)
+		}
+	}
+	if err != nil {
+		fmt.Printf("An Error Occurred: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func loadModel(modelPath string) (lib.O3DModel, error) {
+	o3dFile, err := os.Open(modelPath)
+	if err != nil {
+		return lib.O3DModel{}, err
+	}
+	defer o3dFile.Close()
+
+	return lib.ExtractO3D(o3dFile)
+}
+
+func loadTga(tgaPath string) (image.Image, error) {
+	tgaFile, err := os.Open(tgaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer tgaFile.Close()
+
+	return tga.Decode(tgaFile)
+}
+
+func writePng(pngPath string, img image.Image) error {
+	pngFile, err := os.Create(pngPath)
+	if err != nil {
+		return err
+	}
+	defer pngFile.Close()
+
+	return png.Encode(pngFile, img)
+}
+
+func writeContactSheets(textureResolver *lib.TextureResolver, modelPath, outputDirectory string, tileSize, columns, rows int) error {
+	materialIds := textureResolver.MaterialIds()
+	if modelPath != "" {
+		o3dModel, err := loadModel(modelPath)
+		if err != nil {
+			return err
+		}
+
+		materialIds = lib.O3DMaterialIds(o3dModel)
+	}
+
+	if len(materialIds) == 0 {
+		return fmt.Errorf("no textures found")
+	}
+
+	tilesPerSheet := columns * rows
+	cellWidth := tileSize + tilePadding*2
+	cellHeight := tileSize + labelHeight + tilePadding*2
+	for sheetIndex := 0; sheetIndex*tilesPerSheet < len(materialIds); sheetIndex++ {
+		sheetMaterialIds := materialIds[sheetIndex*tilesPerSheet : min((sheetIndex+1)*tilesPerSheet, len(materialIds))]
+		sheetRows := (len(sheetMaterialIds) + columns - 1) / columns
+
+		sheet := image.NewNRGBA(image.Rect(0, 0, cellWidth*columns, cellHeight*sheetRows))
+		draw.Draw(sheet, sheet.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+		for i, materialId := range sheetMaterialIds {
+			cellX := (i%columns)*cellWidth + tilePadding
+			cellY := (i/columns)*cellHeight + tilePadding
+
+			label := lib.ObjMaterialName(materialId)
+			texturePath, ok := textureResolver.Resolve(materialId)
+			if !ok {
+				fmt.Printf("could not find a texture for material %d\n", materialId)
+				label += "?"
+			} else if texture, err := loadTga(texturePath); err != nil {
+				fmt.Printf("could not load texture `%s`: %v\n", texturePath, err)
+				label += "?"
+			} else {
+				drawScaled(sheet, image.Rect(cellX, cellY, cellX+tileSize, cellY+tileSize), texture)
+			}
+
+			drawLabel(sheet, cellX, cellY+tileSize+(labelHeight-glyphHeight*glyphScale)/2, label)
+		}
+
+		sheetPath := filepath.Join(outputDirectory, fmt.Sprintf("contact_sheet_%03d.png", sheetIndex+1))
+		err := writePng(sheetPath, sheet)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote `%s` (%d textures)\n", sheetPath, len(sheetMaterialIds))
+	}
+
+	return nil
+}
+
+// nearest neighbour scale that keeps the aspect ratio, these are tiny previews so nothing fancier is needed
+func drawScaled(destination *image.NRGBA, area image.Rectangle, source image.Image) {
+	sourceBounds := source.Bounds()
+	scale := min(float64(area.Dx())/float64(sourceBounds.Dx()), float64(area.Dy())/float64(sourceBounds.Dy()))
+	width, height := int(float64(sourceBounds.Dx())*scale), int(float64(sourceBounds.Dy())*scale)
+	offsetX, offsetY := area.Min.X+(area.Dx()-width)/2, area.Min.Y+(area.Dy()-height)/2
+
+	for y := range height {
+		for x := range width {
+			sourceX := sourceBounds.Min.X + int(float64(x)/scale)
+			sourceY := sourceBounds.Min.Y + int(float64(y)/scale)
+			destination.Set(offsetX+x, offsetY+y, source.At(sourceX, sourceY))
+		}
+	}
+}
+
+func writeAtlas(textureResolver *lib.TextureResolver, modelPath, outputDirectory string) error {
+	if modelPath == "" {
+		return fmt.Errorf("atlas mode needs a -model")
+	}
+
+	o3dModel, err := loadModel(modelPath)
+	if err != nil {
+		return err
+	}
+
+	texturePaths, unresolved := textureResolver.ResolveModel(o3dModel)
+	for _, materialId := range unresolved {
+		fmt.Printf("could not find a texture for material %d\n", materialId)
+	}
+
+	textures := make(map[uint16]image.Image)
+	for materialId, texturePath := range texturePaths {
+		texture, err := loadTga(texturePath)
+		if err != nil {
+			fmt.Printf("could not load texture `%s`: %v\n", texturePath, err)
+			continue
+		}
+		textures[materialId] = texture
+	}
+
+	atlas, err := lib.BuildTextureAtlas(textures, 2)
+	if err != nil {
+		return err
+	}
+
+	// keep the lowest packed material id so the model still points at something real in game terms,
+	// faces whose texture was not packed keep their own material
+	atlasMaterialId := slices.Min(slices.Collect(maps.Keys(atlas.Regions)))
+	remapped, clamped := atlas.RemapO3D(o3dModel, atlasMaterialId)
+	if clamped > 0 {
+		fmt.Printf("clamped %d uv coordinates that relied on texture wrapping\n", clamped)
+	}
+	for _, materialId := range lib.O3DMaterialIds(o3dModel) {
+		if _, ok := atlas.Regions[materialId]; !ok {
+			fmt.Printf("faces using material %d were left on it, they are not in the atlas\n", materialId)
+		}
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(modelPath), filepath.Ext(modelPath))
+	atlasFileName := baseName + "_atlas.png"
+	err = writePng(filepath.Join(outputDirectory, atlasFileName), atlas.Image)
+	if err != nil {
+		return err
+	}
+
+	mtlFileName := baseName + "_atlas.mtl"
+	mtlFile, err := os.Create(filepath.Join(outputDirectory, mtlFileName))
+	if err != nil {
+		return err
+	}
+	defer mtlFile.Close()
+
+	err = lib.WriteMtl(mtlFile, remapped, map[uint16]string{atlasMaterialId: atlasFileName})
+	if err != nil {
+		return err
+	}
+
+	objFile, err := os.Create(filepath.Join(outputDirectory, baseName+"_atlas.obj"))
+	if err != nil {
+		return err
+	}
+	defer objFile.Close()
+
+	err = lib.WriteObj(objFile, remapped, mtlFileName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote `%s` packing %d textures\n", atlasFileName, len(textures))
+	return nil
+}
@@ -2,57 +2,222 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+)
+
+const (
+	DefaultProfile = "default"
+
+	// bump when the layout changes and teach upgradeConfigFile about it
+	SchemaVersion = 2
+
+	// set to a file path to keep the config somewhere other than the user config directory
+	configPathEnvironmentVariable = "STONE_TOOLS_CONFIG"
 )
 
 type Config struct {
-	DarkstoneDirectory string `json:"darkstone_directory"`
+	// which profile this was loaded from and will be saved back to
+	Profile string `json:"-"`
+
+	DarkstoneDirectory string           `json:"darkstone_directory"`
+	Extraction         ExtractionConfig `json:"extraction"`
+	Textures           TexturesConfig   `json:"textures"`
+	Archives           ArchivesConfig   `json:"archives"`
+	Viewer             ViewerConfig     `json:"viewer"`
 }
 
+// what is actually on disk, one Config per named profile
+type configFile struct {
+	Version       int               `json:"version"`
+	ActiveProfile string            `json:"active_profile"`
+	Profiles      map[string]Config `json:"profiles"`
+}
+
+// loads whichever profile was used last
 func LoadConfig() (Config, error) {
-	filePath := createConfigFilePath("user_prefs.json")
-	data, err := os.ReadFile(filePath)
+	file, err := loadConfigFile()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return Config{}, nil
-		}
+		return Config{}, err
+	}
 
+	return file.profile(file.ActiveProfile)
+}
+
+// loads the named profile, a profile that does not exist yet comes back empty and is created on save
+func LoadProfile(name string) (Config, error) {
+	file, err := loadConfigFile()
+	if err != nil {
 		return Config{}, err
 	}
 
-	var config Config
-	err = json.Unmarshal(data, &config)
-	return config, err
+	return file.profile(name)
 }
 
 func SaveConfig(c Config) error {
-	filePath := createConfigFilePath("user_prefs.json")
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if c.Profile == "" {
+		c.Profile = DefaultProfile
+	}
+	c.applyDefaults()
+	err = c.Validate()
+	if err != nil {
+		return err
+	}
+	file.Profiles[c.Profile] = c
+	file.ActiveProfile = c.Profile
 
-	data, err := json.Marshal(c)
+	return saveConfigFile(file)
+}
+
+// makes the named profile the one LoadConfig returns, without touching its settings
+func SetActiveProfile(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+
+	file, err := loadConfigFile()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filePath, data, os.ModePerm)
+	if _, ok := file.Profiles[name]; !ok {
+		file.Profiles[name] = Config{}
+	}
+	file.ActiveProfile = name
+
+	return saveConfigFile(file)
 }
 
-func createConfigFilePath(fileName string) string {
-	configDirectory := filepath.Join(".", "config")
-	if !directoryExists(configDirectory) {
-		os.MkdirAll(configDirectory, os.ModePerm)
+func ListProfiles() ([]string, error) {
+	file, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	return filepath.Join(configDirectory, fileName)
+	return names, nil
 }
 
-func directoryExists(path string) bool {
-	_, err := os.Stat(path)
-	if err == nil {
-		return true
+func (f configFile) profile(name string) (Config, error) {
+	if name == "" {
+		name = DefaultProfile
 	}
+
+	c := f.Profiles[name]
+	c.Profile = name
+	c.applyDefaults()
+
+	err := c.Validate()
+	if err != nil {
+		return c, fmt.Errorf("profile `%s`: %v", name, err)
+	}
+
+	return c, nil
+}
+
+func ConfigFilePath() (string, error) {
+	if configPath := os.Getenv(configPathEnvironmentVariable); configPath != "" {
+		return configPath, nil
+	}
+
+	userConfigDirectory, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userConfigDirectory, "stone-tools", "config.json"), nil
+}
+
+func loadConfigFile() (configFile, error) {
+	file := configFile{ActiveProfile: DefaultProfile, Profiles: make(map[string]Config)}
+
+	filePath, err := ConfigFilePath()
+	if err != nil {
+		return file, err
+	}
+
+	data, err := os.ReadFile(filePath)
 	if os.IsNotExist(err) {
-		return false
+		return migrateLegacyConfig(file)
+	}
+	if err != nil {
+		return file, err
 	}
-	return false
+
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return file, fmt.Errorf("could not read config `%s`: %v", filePath, err)
+	}
+
+	if file.Version > SchemaVersion {
+		return file, fmt.Errorf("config `%s` is version %d but this build only understands up to %d", filePath, file.Version, SchemaVersion)
+	}
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]Config)
+	}
+	if file.ActiveProfile == "" {
+		file.ActiveProfile = DefaultProfile
+	}
+
+	return upgradeConfigFile(file), nil
+}
+
+// brings older layouts up to SchemaVersion, saving is left to whoever changes something next
+func upgradeConfigFile(file configFile) configFile {
+	// version 1 (no version field) only had darkstone_directory per profile, the new sections
+	// come in empty and applyDefaults fills them so nothing needs moving around
+	file.Version = SchemaVersion
+	return file
+}
+
+func saveConfigFile(file configFile) error {
+	file.Version = SchemaVersion
+
+	filePath, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, data, 0o644)
+}
+
+// older versions kept a single config in ./config/user_prefs.json relative to wherever they were launched from.
+// it becomes the default profile the first time there is no config in the new location, the old file is left alone
+func migrateLegacyConfig(file configFile) (configFile, error) {
+	data, err := os.ReadFile(filepath.Join(".", "config", "user_prefs.json"))
+	if err != nil {
+		// nothing to migrate
+		return file, nil
+	}
+
+	var legacy Config
+	err = json.Unmarshal(data, &legacy)
+	if err != nil || legacy.DarkstoneDirectory == "" {
+		return file, nil
+	}
+
+	file.Profiles[DefaultProfile] = legacy
+	return file, saveConfigFile(file)
 }
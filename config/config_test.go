@@ -0,0 +1,233 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// points the config at a file in a fresh directory, which does not exist until something is saved
+func useTestConfig(t *testing.T) string {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "stone-tools", "config.json")
+	t.Setenv(configPathEnvironmentVariable, configPath)
+	return configPath
+}
+
+// the legacy config is looked up relative to the working directory
+func chdir(t *testing.T, directory string) {
+	t.Helper()
+
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(workingDirectory) })
+}
+
+func TestConfigFilePathOverride(t *testing.T) {
+	configPath := useTestConfig(t)
+	got, err := ConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != configPath {
+		t.Errorf("ConfigFilePath() = %s, want the %s override %s", got, configPathEnvironmentVariable, configPath)
+	}
+
+	t.Setenv(configPathEnvironmentVariable, "")
+	got, err = ConfigFilePath()
+	if err != nil {
+		t.Skipf("no user config directory here: %v", err)
+	}
+	if filepath.Base(got) != "config.json" || filepath.Base(filepath.Dir(got)) != "stone-tools" {
+		t.Errorf("ConfigFilePath() = %s, want stone-tools/config.json in the user config directory", got)
+	}
+}
+
+func TestMigrateLegacyConfig(t *testing.T) {
+	configPath := useTestConfig(t)
+	legacyDirectory := t.TempDir()
+	chdir(t, legacyDirectory)
+
+	legacyPath := filepath.Join(legacyDirectory, "config", "user_prefs.json")
+	err := os.MkdirAll(filepath.Dir(legacyPath), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := []byte(`{"darkstone_directory": "C:\\Games\\Darkstone"}`)
+	err = os.WriteFile(legacyPath, legacy, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != DefaultProfile || c.DarkstoneDirectory != `C:\Games\Darkstone` {
+		t.Errorf("migrated profile %s with %q, want the legacy directory as %s", c.Profile, c.DarkstoneDirectory, DefaultProfile)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		t.Errorf("the migrated config was not saved: %v", err)
+	}
+	if data, err := os.ReadFile(legacyPath); err != nil || string(data) != string(legacy) {
+		t.Errorf("the legacy config should be left alone, got %q, %v", data, err)
+	}
+
+	// once the new config exists the legacy one is not read again
+	err = os.WriteFile(legacyPath, []byte(`{"darkstone_directory": "elsewhere"}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DarkstoneDirectory != `C:\Games\Darkstone` {
+		t.Errorf("DarkstoneDirectory = %q after a second load, the legacy config was migrated again", c.DarkstoneDirectory)
+	}
+}
+
+func TestMigrateLegacyConfigSkipsUnusable(t *testing.T) {
+	tests := []struct {
+		name   string
+		legacy string
+	}{
+		{"missing", ""},
+		{"not json", "darkstone_directory = C:\\Games"},
+		{"no directory", `{"darkstone_directory": ""}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configPath := useTestConfig(t)
+			legacyDirectory := t.TempDir()
+			chdir(t, legacyDirectory)
+
+			if test.legacy != "" {
+				err := os.MkdirAll(filepath.Join(legacyDirectory, "config"), 0o755)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = os.WriteFile(filepath.Join(legacyDirectory, "config", "user_prefs.json"), []byte(test.legacy), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			c, err := LoadConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.DarkstoneDirectory != "" {
+				t.Errorf("DarkstoneDirectory = %q, want nothing migrated", c.DarkstoneDirectory)
+			}
+			if _, err := os.Stat(configPath); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("a config was written without anything to migrate: %v", err)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	useTestConfig(t)
+	chdir(t, t.TempDir())
+
+	// a profile that does not exist yet loads empty and is not listed until it is saved
+	modded, err := LoadProfile("modded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modded.Profile != "modded" || modded.DarkstoneDirectory != "" {
+		t.Errorf("new profile = %s with %q, want an empty modded", modded.Profile, modded.DarkstoneDirectory)
+	}
+	if names, err := ListProfiles(); err != nil || len(names) != 0 {
+		t.Errorf("ListProfiles() = %v, %v before anything was saved", names, err)
+	}
+
+	modded.DarkstoneDirectory = "modded-install"
+	modded.Extraction.Workers = 3
+	err = SaveConfig(modded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// saving makes the profile the active one
+	c, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != "modded" || c.DarkstoneDirectory != "modded-install" || c.Extraction.Workers != 3 {
+		t.Errorf("LoadConfig() = %s with %q and %d workers, want the saved modded profile", c.Profile, c.DarkstoneDirectory, c.Extraction.Workers)
+	}
+
+	// switching creates the profile without touching the other one
+	err = SetActiveProfile(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != DefaultProfile || c.DarkstoneDirectory != "" {
+		t.Errorf("LoadConfig() = %s with %q after switching, want an empty %s", c.Profile, c.DarkstoneDirectory, DefaultProfile)
+	}
+	if names, err := ListProfiles(); err != nil || !reflect.DeepEqual(names, []string{DefaultProfile, "modded"}) {
+		t.Errorf("ListProfiles() = %v, %v", names, err)
+	}
+
+	modded, err = LoadProfile("modded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modded.DarkstoneDirectory != "modded-install" {
+		t.Errorf("modded lost its directory after switching, got %q", modded.DarkstoneDirectory)
+	}
+
+	if err := SetActiveProfile(""); err == nil {
+		t.Error("want an error switching to a profile without a name")
+	}
+}
+
+func TestSaveConfigRejectsInvalid(t *testing.T) {
+	configPath := useTestConfig(t)
+	chdir(t, t.TempDir())
+
+	c, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Extraction.Workers = 1000
+	if err := SaveConfig(c); err == nil {
+		t.Error("want an error saving 1000 workers")
+	}
+	if _, err := os.Stat(configPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("an invalid config was written: %v", err)
+	}
+}
+
+func TestLoadConfigNewerVersion(t *testing.T) {
+	configPath := useTestConfig(t)
+	err := os.MkdirAll(filepath.Dir(configPath), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(configPath, []byte(`{"version": 99, "profiles": {}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("LoadConfig() error = %v, want one about version 99", err)
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"stone-tools/lib"
+	"strings"
+)
+
+const maxExtractionWorkers = 256
+
+type ExtractionConfig struct {
+	OutputDirectory string              `json:"output_directory"` // each archive gets its own folder inside
+	Workers         int                 `json:"workers"`
+	Archives        int                 `json:"archives"` // extracted at once when several are picked, 1 for one after another
+	Overwrite       lib.OverwritePolicy `json:"overwrite"`
+	Converters      []string            `json:"converters"` // lib.Converter names switched on by default
+	ReplaceRaw      bool                `json:"replace_raw"`
+}
+
+type TexturesConfig struct {
+	ModPaths     []string `json:"mod_paths"` // searched ahead of the game's own textures
+	BankPriority []string `json:"bank_priority"`
+	PreferLowRes bool     `json:"prefer_low_res"`
+}
+
+type ArchivesConfig struct {
+	// lower case sha-1 of untouched archives to the release they shipped with, filled in by the user
+	// from an install or disc they trust since nothing ships with the tools
+	RetailHashes map[string]string `json:"retail_hashes"`
+}
+
+type ViewerConfig struct {
+	// relative paths are relative to wherever the viewer is started from, by default its own directory
+	TexturePaths []string `json:"texture_paths"`
+}
+
+// fills in anything left unset, nil lists count as unset while empty ones were cleared on purpose
+func (c *Config) applyDefaults() {
+	if c.Extraction.OutputDirectory == "" {
+		c.Extraction.OutputDirectory = "out"
+	}
+	if c.Extraction.Workers == 0 {
+		// clamped so a very wide machine still passes Validate
+		c.Extraction.Workers = min(runtime.NumCPU(), maxExtractionWorkers)
+	}
+	if c.Extraction.Archives == 0 {
+		c.Extraction.Archives = 1
+	}
+	if c.Extraction.Overwrite == "" {
+		c.Extraction.Overwrite = lib.OverwriteAlways
+	}
+	if c.Extraction.Converters == nil {
+		c.Extraction.Converters = []string{}
+	}
+
+	if c.Textures.ModPaths == nil {
+		c.Textures.ModPaths = []string{}
+	}
+	if c.Textures.BankPriority == nil {
+		c.Textures.BankPriority = []string{"DRAGONBLADE"}
+	}
+
+	if c.Archives.RetailHashes == nil {
+		c.Archives.RetailHashes = map[string]string{}
+	}
+
+	if c.Viewer.TexturePaths == nil {
+		c.Viewer.TexturePaths = []string{
+			filepath.Join("..", "..", "mods"),
+			filepath.Join("..", "..", "out", "data", "DATA", "BANKDATABASE"),
+		}
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Extraction.OutputDirectory == "" {
+		return fmt.Errorf("extraction output directory cannot be empty")
+	}
+	if c.Extraction.Workers < 1 || c.Extraction.Workers > maxExtractionWorkers {
+		return fmt.Errorf("extraction workers must be between 1 and %d, not %d", maxExtractionWorkers, c.Extraction.Workers)
+	}
+	if c.Extraction.Archives < 1 || c.Extraction.Archives > maxExtractionWorkers {
+		return fmt.Errorf("archives extracted at once must be between 1 and %d, not %d", maxExtractionWorkers, c.Extraction.Archives)
+	}
+
+	knownPolicy := false
+	for _, policy := range lib.OverwritePolicies {
+		knownPolicy = knownPolicy || policy == c.Extraction.Overwrite
+	}
+	if !knownPolicy {
+		return fmt.Errorf("unknown overwrite policy `%s`, expected one of %v", c.Extraction.Overwrite, lib.OverwritePolicies)
+	}
+
+	for _, converterName := range c.Extraction.Converters {
+		if _, ok := lib.ConverterByName(converterName); !ok {
+			return fmt.Errorf("unknown converter `%s`", converterName)
+		}
+	}
+
+	for _, paths := range [][]string{c.Textures.ModPaths, c.Viewer.TexturePaths} {
+		for _, path := range paths {
+			if path == "" {
+				return fmt.Errorf("texture search paths cannot be empty")
+			}
+		}
+	}
+
+	for hash, release := range c.Archives.RetailHashes {
+		if !isSha1(hash) {
+			return fmt.Errorf("retail hash `%s` is not a lower case sha-1", hash)
+		}
+		if release == "" {
+			return fmt.Errorf("retail hash `%s` has no release name", hash)
+		}
+	}
+
+	return nil
+}
+
+func isSha1(hash string) bool {
+	if len(hash) != sha1.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// the retail release an archive with this sha-1 shipped with, empty when it is not a known one
+func (c Config) RetailRelease(hash string) string {
+	return c.Archives.RetailHashes[strings.ToLower(hash)]
+}
+
+// the extraction settings as lib understands them
+func (c Config) ConvertOptions() lib.ConvertOptions {
+	convertOptions := lib.ConvertOptions{Replace: c.Extraction.ReplaceRaw, Overwrite: c.Extraction.Overwrite}
+	for _, converterName := range c.Extraction.Converters {
+		if converter, ok := lib.ConverterByName(converterName); ok {
+			convertOptions.Converters = append(convertOptions.Converters, converter)
+		}
+	}
+
+	return convertOptions
+}
+
+// mods first so they win over the game's own textures
+func (c Config) TextureResolver(texturePaths ...string) *lib.TextureResolver {
+	textureResolver := lib.NewTextureResolver(append(append([]string{}, c.Textures.ModPaths...), texturePaths...)...)
+	textureResolver.BankPriority = c.Textures.BankPriority
+	textureResolver.PreferLowRes = c.Textures.PreferLowRes
+	return textureResolver
+}
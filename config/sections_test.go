@@ -0,0 +1,149 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"stone-tools/lib"
+	"strings"
+	"testing"
+)
+
+const testSha1 = "4ba64ab0c0ffee00112233445566778899aabbcc"
+
+func TestRetailHashesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hashes  map[string]string
+		wantErr string
+	}{
+		{"none", nil, ""},
+		{"valid", map[string]string{testSha1: "1.0 (retail)"}, ""},
+		{"upper case", map[string]string{strings.ToUpper(testSha1): "1.0"}, "not a lower case sha-1"},
+		{"too short", map[string]string{testSha1[:39]: "1.0"}, "not a lower case sha-1"},
+		{"not hex", map[string]string{"z" + testSha1[1:]: "1.0"}, "not a lower case sha-1"},
+		{"no release", map[string]string{testSha1: ""}, "has no release name"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Config{Archives: ArchivesConfig{RetailHashes: test.hashes}}
+			c.applyDefaults()
+
+			err := c.Validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetailRelease(t *testing.T) {
+	c := Config{Archives: ArchivesConfig{RetailHashes: map[string]string{testSha1: "1.0 (retail)"}}}
+
+	if release := c.RetailRelease(strings.ToUpper(testSha1)); release != "1.0 (retail)" {
+		t.Errorf("RetailRelease = %q, want the configured release", release)
+	}
+	if release := c.RetailRelease(strings.Repeat("0", 40)); release != "" {
+		t.Errorf("RetailRelease of an unknown hash = %q", release)
+	}
+
+	var empty Config
+	empty.applyDefaults()
+	if empty.Archives.RetailHashes == nil || len(empty.Archives.RetailHashes) != 0 {
+		t.Errorf("defaults left retail hashes as %v, want an empty map", empty.Archives.RetailHashes)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var c Config
+	c.applyDefaults()
+
+	if c.Extraction.OutputDirectory != "out" {
+		t.Errorf("OutputDirectory = %q, want out", c.Extraction.OutputDirectory)
+	}
+	if want := min(runtime.NumCPU(), maxExtractionWorkers); c.Extraction.Workers != want {
+		t.Errorf("Workers = %d, want %d", c.Extraction.Workers, want)
+	}
+	if c.Extraction.Archives != 1 {
+		t.Errorf("Archives = %d, want 1", c.Extraction.Archives)
+	}
+	if c.Extraction.Overwrite != lib.OverwriteAlways {
+		t.Errorf("Overwrite = %s, want %s", c.Extraction.Overwrite, lib.OverwriteAlways)
+	}
+	if c.Extraction.Converters == nil || len(c.Extraction.Converters) != 0 {
+		t.Errorf("Converters = %v, want an empty list", c.Extraction.Converters)
+	}
+	if !reflect.DeepEqual(c.Textures.BankPriority, []string{"DRAGONBLADE"}) {
+		t.Errorf("BankPriority = %v, want DRAGONBLADE first", c.Textures.BankPriority)
+	}
+	wantTexturePaths := []string{
+		filepath.Join("..", "..", "mods"),
+		filepath.Join("..", "..", "out", "data", "DATA", "BANKDATABASE"),
+	}
+	if !reflect.DeepEqual(c.Viewer.TexturePaths, wantTexturePaths) {
+		t.Errorf("viewer TexturePaths = %v, want %v", c.Viewer.TexturePaths, wantTexturePaths)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("the defaults do not validate: %v", err)
+	}
+
+	// set values and lists cleared on purpose are kept
+	c = Config{
+		Extraction: ExtractionConfig{Workers: 3, Overwrite: lib.OverwriteSkip, Converters: []string{"png"}},
+		Textures:   TexturesConfig{BankPriority: []string{}},
+	}
+	c.applyDefaults()
+	if c.Extraction.Workers != 3 || c.Extraction.Overwrite != lib.OverwriteSkip || !reflect.DeepEqual(c.Extraction.Converters, []string{"png"}) {
+		t.Errorf("defaults replaced set values: %+v", c.Extraction)
+	}
+	if c.Textures.BankPriority == nil || len(c.Textures.BankPriority) != 0 {
+		t.Errorf("BankPriority = %v, the cleared list should stay empty", c.Textures.BankPriority)
+	}
+}
+
+func TestValidateExtraction(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{"one worker", func(c *Config) { c.Extraction.Workers = 1 }, ""},
+		{"most workers", func(c *Config) { c.Extraction.Workers = maxExtractionWorkers }, ""},
+		{"negative workers", func(c *Config) { c.Extraction.Workers = -1 }, "extraction workers must be between"},
+		{"too many workers", func(c *Config) { c.Extraction.Workers = maxExtractionWorkers + 1 }, "extraction workers must be between"},
+		{"no archives", func(c *Config) { c.Extraction.Archives = -1 }, "archives extracted at once"},
+		{"too many archives", func(c *Config) { c.Extraction.Archives = maxExtractionWorkers + 1 }, "archives extracted at once"},
+		{"skip", func(c *Config) { c.Extraction.Overwrite = lib.OverwriteSkip }, ""},
+		{"rename", func(c *Config) { c.Extraction.Overwrite = lib.OverwriteRename }, ""},
+		{"unknown policy", func(c *Config) { c.Extraction.Overwrite = "merge" }, "unknown overwrite policy `merge`"},
+		{"policy is case sensitive", func(c *Config) { c.Extraction.Overwrite = "Skip" }, "unknown overwrite policy"},
+		{"known converters", func(c *Config) { c.Extraction.Converters = []string{"png", "gltf", "utf8"} }, ""},
+		{"unknown converter", func(c *Config) { c.Extraction.Converters = []string{"png", "bmp"} }, "unknown converter `bmp`"},
+		{"empty output directory", func(c *Config) { c.Extraction.OutputDirectory = "" }, "output directory cannot be empty"},
+		{"empty texture path", func(c *Config) { c.Textures.ModPaths = []string{""} }, "texture search paths cannot be empty"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var c Config
+			c.applyDefaults()
+			test.modify(&c)
+
+			err := c.Validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
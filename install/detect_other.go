@@ -0,0 +1,35 @@
+//go:build !windows
+
+package install
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func detectPlatform() []Install {
+	installs := make([]Install, 0)
+
+	userHomeDirectory, err := os.UserHomeDir()
+	if err == nil {
+		installs = append(installs, detectSteam([]string{
+			filepath.Join(userHomeDirectory, ".steam", "steam"),
+			filepath.Join(userHomeDirectory, ".local", "share", "Steam"),
+			filepath.Join(userHomeDirectory, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+		})...)
+		installs = append(installs, detectHeroic([]string{
+			filepath.Join(userHomeDirectory, ".config", "heroic"),
+			filepath.Join(userHomeDirectory, ".var", "app", "com.heroicgameslauncher.hgl", "config", "heroic"),
+		})...)
+		installs = append(installs, detectLutris([]string{
+			filepath.Join(userHomeDirectory, ".config", "lutris", "games"),
+			filepath.Join(userHomeDirectory, ".local", "share", "lutris", "games"),
+		})...)
+	}
+
+	for _, prefix := range wineprefixes() {
+		installs = append(installs, detectWineprefix(prefix)...)
+	}
+
+	return installs
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+func detectPlatform() []Install {
+	installs := make([]Install, 0)
+	for _, keyPath := range possibleWindowRegKeys {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+
+		for _, valueName := range possibleWindowsRegValues {
+			value, _, err := key.GetStringValue(valueName)
+			if err != nil || value == "" {
+				continue
+			}
+
+			installs = append(installs, Install{Path: value, Source: "registry"})
+		}
+		key.Close()
+	}
+
+	installs = append(installs, detectGogGalaxy()...)
+	installs = append(installs, detectSteam(steamRoots())...)
+
+	return installs
+}
+
+func steamRoots() []string {
+	steamRoots := make([]string, 0)
+
+	key, err := registry.OpenKey(registry.CURRENT_USER, "Software\\Valve\\Steam", registry.QUERY_VALUE)
+	if err == nil {
+		steamPath, _, err := key.GetStringValue("SteamPath")
+		if err == nil && steamPath != "" {
+			steamRoots = append(steamRoots, filepath.Clean(steamPath))
+		}
+		key.Close()
+	}
+
+	if programFiles := os.Getenv("ProgramFiles(x86)"); programFiles != "" {
+		steamRoots = append(steamRoots, filepath.Join(programFiles, "Steam"))
+	}
+
+	return steamRoots
+}
+
+// galaxy registers every game under GOG.com\Games\<game id> with its name and install path
+func detectGogGalaxy() []Install {
+	installs := make([]Install, 0)
+	for _, gamesPath := range []string{"SOFTWARE\\WOW6432Node\\GOG.com\\Games", "SOFTWARE\\GOG.com\\Games"} {
+		gamesKey, err := registry.OpenKey(registry.LOCAL_MACHINE, gamesPath, registry.ENUMERATE_SUB_KEYS)
+		if err != nil {
+			continue
+		}
+
+		gameIds, err := gamesKey.ReadSubKeyNames(-1)
+		gamesKey.Close()
+		if err != nil {
+			continue
+		}
+
+		for _, gameId := range gameIds {
+			gameKey, err := registry.OpenKey(registry.LOCAL_MACHINE, gamesPath+"\\"+gameId, registry.QUERY_VALUE)
+			if err != nil {
+				continue
+			}
+
+			gameName, _, _ := gameKey.GetStringValue("gameName")
+			path, _, _ := gameKey.GetStringValue("path")
+			gameKey.Close()
+
+			if strings.Contains(strings.ToLower(gameName), "darkstone") && path != "" {
+				installs = append(installs, Install{Path: path, Source: "gog galaxy"})
+			}
+		}
+	}
+
+	return installs
+}
@@ -0,0 +1,96 @@
+package install
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type heroicInstalled struct {
+	Installed []struct {
+		AppName     string `json:"appName"`
+		InstallPath string `json:"install_path"`
+	} `json:"installed"`
+}
+
+// heroic keeps its gog installs in gog_store/installed.json, which only has paths so match on those
+func detectHeroic(configDirectories []string) []Install {
+	installs := make([]Install, 0)
+	for _, configDirectory := range configDirectories {
+		data, err := os.ReadFile(filepath.Join(configDirectory, "gog_store", "installed.json"))
+		if err != nil {
+			continue
+		}
+
+		var installed heroicInstalled
+		err = json.Unmarshal(data, &installed)
+		if err != nil {
+			continue
+		}
+
+		for _, game := range installed.Installed {
+			if !strings.Contains(strings.ToLower(game.InstallPath), "darkstone") {
+				continue
+			}
+
+			installs = append(installs, Install{Path: game.InstallPath, Source: "gog (heroic)"})
+		}
+	}
+
+	return installs
+}
+
+// lutris writes one yml per game, the game's exe lives in the install directory and its prefix may hold the registry keys too
+func detectLutris(gameDirectories []string) []Install {
+	installs := make([]Install, 0)
+	for _, gameDirectory := range gameDirectories {
+		gameFiles, _ := filepath.Glob(filepath.Join(gameDirectory, "*.yml"))
+		for _, gameFile := range gameFiles {
+			exe, prefix := readLutrisGame(gameFile)
+			isDarkstone := strings.Contains(strings.ToLower(filepath.Base(gameFile)), "darkstone") ||
+				strings.Contains(strings.ToLower(exe), "darkstone")
+			if !isDarkstone {
+				continue
+			}
+
+			if exe != "" && filepath.IsAbs(exe) {
+				installs = append(installs, Install{Path: filepath.Dir(exe), Source: "lutris (" + filepath.Base(gameFile) + ")"})
+			}
+			if prefix != "" {
+				installs = append(installs, detectWineprefix(prefix)...)
+			}
+		}
+	}
+
+	return installs
+}
+
+// only the two keys we care about are pulled out, no need for a full yaml parser
+func readLutrisGame(path string) (string, string) {
+	gameFile, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer gameFile.Close()
+
+	exe, prefix := "", ""
+	scanner := bufio.NewScanner(gameFile)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch key {
+		case "exe":
+			exe = value
+		case "prefix":
+			prefix = value
+		}
+	}
+
+	return exe, prefix
+}
@@ -0,0 +1,104 @@
+package install
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectHeroic(t *testing.T) {
+	configDirectory := t.TempDir()
+	installed := map[string]any{
+		"installed": []map[string]string{
+			{"appName": "1207658700", "install_path": "/home/player/Games/Heroic/Darkstone"},
+			{"appName": "1207658930", "install_path": "/home/player/Games/Heroic/Other Game"},
+		},
+	}
+	data, err := json.Marshal(installed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(configDirectory, "gog_store"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(configDirectory, "gog_store", "installed.json"), data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broken := t.TempDir()
+	err = os.MkdirAll(filepath.Join(broken, "gog_store"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(broken, "gog_store", "installed.json"), []byte("{not json"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installs := detectHeroic([]string{configDirectory, broken, filepath.Join(configDirectory, "missing")})
+	want := Install{Path: "/home/player/Games/Heroic/Darkstone", Source: "gog (heroic)"}
+	if len(installs) != 1 || installs[0] != want {
+		t.Errorf("detectHeroic = %+v, want %+v", installs, want)
+	}
+}
+
+func TestDetectLutris(t *testing.T) {
+	prefix, otherDrive := testWineprefix(t)
+	copyFixture(t, "system.reg", filepath.Join(prefix, "system.reg"))
+	drivePath := evalSymlinks(t, filepath.Join(prefix, "drive_c", "GOG Games", "Darkstone"))
+
+	gameDirectory := t.TempDir()
+	games := map[string]string{
+		// found through the exe and the prefix's registry
+		"darkstone-1700000000.yml": "game:\n  exe: /home/player/Games/darkstone/drive_c/Darkstone/darkstone.exe\n  prefix: '" + prefix + "'\nsystem: {}\n",
+		// named after the game, the relative exe is useless so only the missing prefix is tried
+		"darkstone-gog.yml": "game:\n  exe: \"darkstone.exe\"\n  prefix: " + filepath.Join(prefix, "missing") + "\n",
+		// recognised by the exe alone
+		"delphine-1.yml": "game:\n  exe: /opt/Darkstone/DARKSTONE.EXE\n",
+		"other-game.yml": "game:\n  exe: /opt/other/game.exe\n  prefix: '" + prefix + "'\n",
+		"notes.txt":      "exe: /opt/Darkstone/darkstone.exe\n",
+	}
+	for name, data := range games {
+		err := os.WriteFile(filepath.Join(gameDirectory, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	installs := detectLutris([]string{gameDirectory, filepath.Join(gameDirectory, "missing")})
+	want := []Install{
+		{Path: "/home/player/Games/darkstone/drive_c/Darkstone", Source: "lutris (darkstone-1700000000.yml)"},
+		// the prefix holds both registry keys, the d: one included
+		{Path: evalSymlinks(t, filepath.Join(otherDrive, "Darkstone \"Gold\"")), Source: "wine (" + prefix + ")"},
+		{Path: drivePath, Source: "wine (" + prefix + ")"},
+		{Path: "/opt/Darkstone", Source: "lutris (delphine-1.yml)"},
+	}
+	byPath := func(a, b Install) int { return strings.Compare(a.Path, b.Path) }
+	slices.SortFunc(installs, byPath)
+	slices.SortFunc(want, byPath)
+	if !slices.Equal(installs, want) {
+		t.Errorf("detectLutris = %+v, want %+v", installs, want)
+	}
+}
+
+func TestReadLutrisGame(t *testing.T) {
+	gameFile := filepath.Join(t.TempDir(), "darkstone.yml")
+	err := os.WriteFile(gameFile, []byte("game:\n  exe: \"/games/Dark Stone/darkstone.exe\"\n  prefix: '/games/prefix'\n  args: -window\nname: Darkstone\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exe, prefix := readLutrisGame(gameFile)
+	if exe != "/games/Dark Stone/darkstone.exe" || prefix != "/games/prefix" {
+		t.Errorf("readLutrisGame = %q, %q", exe, prefix)
+	}
+
+	if exe, prefix := readLutrisGame(filepath.Join(t.TempDir(), "missing.yml")); exe != "" || prefix != "" {
+		t.Errorf("missing file read as %q, %q", exe, prefix)
+	}
+}
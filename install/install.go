@@ -0,0 +1,56 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// registry keys, relative to HKEY_LOCAL_MACHINE, the different releases of Darkstone have used
+var possibleWindowRegKeys = []string{
+	"SOFTWARE\\WOW6432Node\\DelphineSoft\\Darkstone\\CurrentVersion\\Darkstone",
+	"SOFTWARE\\DelphineSoft\\Darkstone\\CurrentVersion\\Darkstone",
+	"SOFTWARE\\WOW6432Node\\Delphine Software\\Darkstone\\CurrentVersion\\Darkstone",
+	"SOFTWARE\\Delphine Software\\Darkstone\\CurrentVersion\\Darkstone",
+	"SOFTWARE\\WOW6432Node\\Delphine Software\\Darkstone",
+	"SOFTWARE\\Delphine Software\\Darkstone",
+}
+
+var possibleWindowsRegValues = []string{
+	"DataPath",
+	"InstallPath",
+}
+
+type Install struct {
+	Path   string
+	Source string // where the install was found, e.g. "registry" or "wine (~/.wine)"
+}
+
+// finds every Darkstone install this platform knows how to look for, best guesses first
+func Detect() []Install {
+	installs := make([]Install, 0)
+	for _, install := range detectPlatform() {
+		install.Path = filepath.Clean(install.Path)
+		if !directoryExists(install.Path) || containsPath(installs, install.Path) {
+			continue
+		}
+
+		installs = append(installs, install)
+	}
+
+	return installs
+}
+
+func containsPath(installs []Install, path string) bool {
+	for _, install := range installs {
+		if install.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+func directoryExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
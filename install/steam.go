@@ -0,0 +1,190 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// a parsed valve KeyValues (.vdf/.acf) node, leaves have a Value and sections have Children
+type vdfNode struct {
+	Value    string
+	Children map[string]*vdfNode
+}
+
+func (n *vdfNode) child(key string) *vdfNode {
+	if n == nil || n.Children == nil {
+		return nil
+	}
+
+	return n.Children[strings.ToLower(key)]
+}
+
+func (n *vdfNode) value(key string) string {
+	child := n.child(key)
+	if child == nil {
+		return ""
+	}
+
+	return child.Value
+}
+
+func parseVdf(reader io.Reader) (*vdfNode, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := tokenizeVdf(string(data))
+	root := &vdfNode{Children: make(map[string]*vdfNode)}
+	stack := []*vdfNode{root}
+	for i := 0; i < len(tokens); i++ {
+		current := stack[len(stack)-1]
+		switch tokens[i] {
+		case "}":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unexpected closing brace")
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		case "{":
+			return nil, fmt.Errorf("section without a name")
+		}
+
+		key := strings.ToLower(tokens[i])
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("key `%s` has no value", key)
+		}
+
+		i++
+		if tokens[i] == "{" {
+			section := &vdfNode{Children: make(map[string]*vdfNode)}
+			current.Children[key] = section
+			stack = append(stack, section)
+			continue
+		}
+
+		current.Children[key] = &vdfNode{Value: tokens[i]}
+	}
+
+	return root, nil
+}
+
+// splits into quoted strings, bare words and braces, dropping // comments and [$CONDITIONALS]
+func tokenizeVdf(data string) []string {
+	tokens := make([]string, 0)
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+		case c == '{' || c == '}':
+			tokens = append(tokens, string(c))
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '[':
+			for i < len(data) && data[i] != ']' {
+				i++
+			}
+		case c == '"':
+			var builder strings.Builder
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' && i+1 < len(data) {
+					i++
+					switch data[i] {
+					case 'n':
+						builder.WriteByte('\n')
+					case 't':
+						builder.WriteByte('\t')
+					default:
+						builder.WriteByte(data[i])
+					}
+					continue
+				}
+				builder.WriteByte(data[i])
+			}
+			tokens = append(tokens, builder.String())
+		default:
+			start := i
+			for i < len(data) && !strings.ContainsRune(" \t\r\n{}\"", rune(data[i])) {
+				i++
+			}
+			tokens = append(tokens, data[start:i])
+			i--
+		}
+	}
+
+	return tokens
+}
+
+func parseVdfFile(path string) (*vdfNode, error) {
+	vdfFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer vdfFile.Close()
+
+	return parseVdf(vdfFile)
+}
+
+// every library folder a steam install knows about, the steam directory itself included
+func steamLibraries(steamRoot string) []string {
+	libraries := []string{steamRoot}
+	for _, libraryFoldersPath := range []string{
+		filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"),
+		filepath.Join(steamRoot, "config", "libraryfolders.vdf"),
+	} {
+		root, err := parseVdfFile(libraryFoldersPath)
+		if err != nil {
+			continue
+		}
+
+		// newer files nest a "path" in each numbered section, older ones map the number straight to the path
+		// and keep a few settings next to them
+		for key, folder := range root.child("libraryfolders").Children {
+			if _, err := strconv.Atoi(key); err != nil {
+				continue
+			}
+
+			path := folder.Value
+			if folder.Children != nil {
+				path = folder.value("path")
+			}
+			if path != "" {
+				libraries = append(libraries, path)
+			}
+		}
+	}
+
+	return libraries
+}
+
+func detectSteam(steamRoots []string) []Install {
+	installs := make([]Install, 0)
+	for _, steamRoot := range steamRoots {
+		for _, library := range steamLibraries(steamRoot) {
+			manifests, _ := filepath.Glob(filepath.Join(library, "steamapps", "appmanifest_*.acf"))
+			for _, manifestPath := range manifests {
+				root, err := parseVdfFile(manifestPath)
+				if err != nil {
+					continue
+				}
+
+				appState := root.child("AppState")
+				if !strings.Contains(strings.ToLower(appState.value("name")), "darkstone") || appState.value("installdir") == "" {
+					continue
+				}
+
+				installs = append(installs, Install{
+					Path:   filepath.Join(library, "steamapps", "common", appState.value("installdir")),
+					Source: "steam (" + library + ")",
+				})
+			}
+		}
+	}
+
+	return installs
+}
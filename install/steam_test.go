@@ -0,0 +1,129 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func copyFixture(t *testing.T, name, destination string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Dir(destination), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(destination, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseVdf(t *testing.T) {
+	root, err := parseVdfFile(filepath.Join("testdata", "libraryfolders.vdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folders := root.child("LibraryFolders")
+	tests := []struct {
+		node *vdfNode
+		key  string
+		want string
+	}{
+		{folders.child("0"), "path", "/home/player/.local/share/Steam"},
+		{folders.child("0").child("apps"), "228980", "377982862"},
+		{folders.child("1"), "path", "/mnt/games/Steam Library"},
+		{folders.child("1"), "LABEL", `games "ssd"`},
+		{folders.child("2"), "path", ""},
+		{root, "missing", ""},
+	}
+	for _, test := range tests {
+		if got := test.node.value(test.key); got != test.want {
+			t.Errorf("value(%s) = %q, want %q", test.key, got, test.want)
+		}
+	}
+
+	manifest, err := parseVdfFile(filepath.Join("testdata", "appmanifest_darkstone.acf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	appState := manifest.child("appstate")
+	if appState.value("installdir") != "Darkstone" || appState.child("UserConfig").value("language") != "english" {
+		t.Errorf("manifest parsed as %+v", appState.Children)
+	}
+	// the [$WIN32] conditional is dropped, leaving an empty section
+	if depots := appState.child("InstalledDepots"); depots == nil || len(depots.Children) != 0 {
+		t.Errorf("InstalledDepots = %+v, want an empty section", depots)
+	}
+}
+
+func TestParseVdfMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"closing brace without a section", `"a" "b" }`},
+		{"section without a name", `{ "a" "b" }`},
+		{"key without a value", `"section" { "key" } "dangling"`},
+	}
+	for _, test := range tests {
+		if _, err := parseVdf(strings.NewReader(test.data)); err == nil {
+			t.Errorf("%s: want an error", test.name)
+		}
+	}
+}
+
+func TestSteamLibraries(t *testing.T) {
+	newFormat := t.TempDir()
+	copyFixture(t, "libraryfolders.vdf", filepath.Join(newFormat, "steamapps", "libraryfolders.vdf"))
+	oldFormat := t.TempDir()
+	copyFixture(t, "libraryfolders_old.vdf", filepath.Join(oldFormat, "config", "libraryfolders.vdf"))
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		steamRoot string
+		want      []string
+	}{
+		{newFormat, []string{newFormat, "/home/player/.local/share/Steam", "/mnt/games/Steam Library"}},
+		// the old format keeps a few settings next to the numbered libraries
+		{oldFormat, []string{oldFormat, `D:\SteamLibrary`}},
+		{missing, []string{missing}},
+	}
+	for _, test := range tests {
+		got := steamLibraries(test.steamRoot)
+		// map order is random, the steam root always comes first
+		slices.Sort(got[1:])
+		if !slices.Equal(got, test.want) {
+			t.Errorf("steamLibraries(%s) = %q, want %q", test.steamRoot, got, test.want)
+		}
+	}
+}
+
+func TestDetectSteam(t *testing.T) {
+	steamRoot := t.TempDir()
+	library := t.TempDir()
+	copyFixture(t, "appmanifest_other.acf", filepath.Join(steamRoot, "steamapps", "appmanifest_228980.acf"))
+	copyFixture(t, "appmanifest_darkstone.acf", filepath.Join(library, "steamapps", "appmanifest_1000010.acf"))
+
+	libraryFolders := "\"libraryfolders\"\n{\n\t\"1\"\n\t{\n\t\t\"path\"\t\t\"" + strings.ReplaceAll(library, `\`, `\\`) + "\"\n\t}\n}\n"
+	err := os.WriteFile(filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"), []byte(libraryFolders), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installs := detectSteam([]string{steamRoot, filepath.Join(steamRoot, "missing")})
+	want := Install{
+		Path:   filepath.Join(library, "steamapps", "common", "Darkstone"),
+		Source: "steam (" + library + ")",
+	}
+	if len(installs) != 1 || installs[0] != want {
+		t.Errorf("detectSteam = %+v, want %+v", installs, want)
+	}
+}
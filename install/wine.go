@@ -0,0 +1,213 @@
+package install
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// the usual places wine prefixes end up, $WINEPREFIX wins when it is set
+func wineprefixes() []string {
+	prefixes := make([]string, 0)
+	if prefix := os.Getenv("WINEPREFIX"); prefix != "" {
+		prefixes = append(prefixes, prefix)
+	}
+
+	userHomeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return prefixes
+	}
+
+	prefixes = append(prefixes, filepath.Join(userHomeDirectory, ".wine"))
+	for _, pattern := range []string{
+		filepath.Join(userHomeDirectory, ".local", "share", "wineprefixes", "*"),
+		filepath.Join(userHomeDirectory, ".PlayOnLinux", "wineprefix", "*"),
+		filepath.Join(userHomeDirectory, "Games", "*"),
+	} {
+		matches, _ := filepath.Glob(pattern)
+		prefixes = append(prefixes, matches...)
+	}
+
+	return prefixes
+}
+
+func detectWineprefix(prefix string) []Install {
+	registryFile, err := os.Open(filepath.Join(prefix, "system.reg"))
+	if err != nil {
+		return nil
+	}
+	defer registryFile.Close()
+
+	values, err := parseWineRegistry(registryFile)
+	if err != nil {
+		return nil
+	}
+
+	installs := make([]Install, 0)
+	for _, keyPath := range possibleWindowRegKeys {
+		key, ok := values[strings.ToLower(keyPath)]
+		if !ok {
+			continue
+		}
+
+		for _, valueName := range possibleWindowsRegValues {
+			value, ok := key[strings.ToLower(valueName)]
+			if !ok || value == "" {
+				continue
+			}
+
+			installs = append(installs, Install{
+				Path:   translateWinePath(prefix, value),
+				Source: "wine (" + prefix + ")",
+			})
+		}
+	}
+
+	return installs
+}
+
+// reads the string values out of a wine .reg file, keyed by lower cased key path then lower cased value name.
+// keys look like `[Software\\DelphineSoft\\Darkstone] 1234` and values like `"DataPath"="C:\\Games\\Darkstone"`,
+// expandable strings come as `str(2):"..."` or utf-16 `hex(2):43,00,...` and are read as they are, without expanding
+func parseWineRegistry(reader io.Reader) (map[string]map[string]string, error) {
+	values := make(map[string]map[string]string)
+
+	var currentKey map[string]string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// long hex values are wrapped with a trailing backslash
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			line = line[:len(line)-1] + strings.TrimSpace(scanner.Text())
+		}
+
+		switch {
+		case strings.HasPrefix(line, "["):
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				currentKey = nil
+				continue
+			}
+
+			keyPath := strings.ToLower(unescapeWineString(line[1:end]))
+			currentKey = values[keyPath]
+			if currentKey == nil {
+				currentKey = make(map[string]string)
+				values[keyPath] = currentKey
+			}
+		case strings.HasPrefix(line, "\"") && currentKey != nil:
+			name, rest, ok := readWineQuoted(line)
+			if !ok || !strings.HasPrefix(rest, "=") {
+				continue
+			}
+
+			// only strings matter here, dword and other hex values are skipped
+			value, ok := readWineString(rest[1:])
+			if !ok {
+				continue
+			}
+
+			currentKey[strings.ToLower(name)] = value
+		}
+	}
+
+	return values, scanner.Err()
+}
+
+// reads a quoted, backslash escaped string off the front of s, returning it unescaped along with whatever followed it
+func readWineQuoted(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, "\"") {
+		return "", s, false
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return unescapeWineString(s[1:i]), s[i+1:], true
+		}
+	}
+
+	return "", s, false
+}
+
+func readWineString(s string) (string, bool) {
+	if hex, ok := strings.CutPrefix(s, "hex(2):"); ok {
+		return decodeWineHexString(hex)
+	}
+
+	value, _, ok := readWineQuoted(strings.TrimPrefix(s, "str(2):"))
+	return value, ok
+}
+
+// hex(2) values are the raw utf-16le bytes, terminating nul included
+func decodeWineHexString(hex string) (string, bool) {
+	data := make([]byte, 0, len(hex)/3+1)
+	for _, field := range strings.Split(hex, ",") {
+		value, err := strconv.ParseUint(strings.TrimSpace(field), 16, 8)
+		if err != nil {
+			return "", false
+		}
+		data = append(data, byte(value))
+	}
+	if len(data)%2 != 0 {
+		return "", false
+	}
+
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i < len(data); i += 2 {
+		units = append(units, binary.LittleEndian.Uint16(data[i:]))
+	}
+
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00"), true
+}
+
+func unescapeWineString(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			default:
+				builder.WriteByte(s[i])
+			}
+			continue
+		}
+		builder.WriteByte(s[i])
+	}
+
+	return builder.String()
+}
+
+// turns `C:\Games\Darkstone` into a real path through the prefix's dosdevices links, falling back to drive_c
+func translateWinePath(prefix, windowsPath string) string {
+	if len(windowsPath) < 2 || windowsPath[1] != ':' {
+		return filepath.FromSlash(strings.ReplaceAll(windowsPath, "\\", "/"))
+	}
+
+	drive := strings.ToLower(windowsPath[:2])
+	rest := strings.TrimLeft(strings.ReplaceAll(windowsPath[2:], "\\", "/"), "/")
+
+	driveRoot := filepath.Join(prefix, "dosdevices", drive)
+	if _, err := os.Stat(driveRoot); err != nil && drive == "c:" {
+		driveRoot = filepath.Join(prefix, "drive_c")
+	}
+
+	translatedPath := filepath.Join(driveRoot, filepath.FromSlash(rest))
+	if resolvedPath, err := filepath.EvalSymlinks(translatedPath); err == nil {
+		return resolvedPath
+	}
+
+	return translatedPath
+}
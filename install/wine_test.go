@@ -0,0 +1,166 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseWineRegistry(t *testing.T) {
+	registryFile, err := os.Open(filepath.Join("testdata", "system.reg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registryFile.Close()
+
+	values, err := parseWineRegistry(registryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, name string
+		want      string
+		ok        bool
+	}{
+		{`software\delphine software\darkstone`, "installpath", `C:\GOG Games\Darkstone`, true},
+		{`software\wow6432node\delphinesoft\darkstone\currentversion\darkstone`, "datapath", `D:\Darkstone "Gold"`, true},
+		{`software\wow6432node\delphinesoft\darkstone\currentversion\darkstone`, "language", `%WINDIR%\English`, true},
+		{`software\microsoft\windows\currentversion`, "programfilesdir", `C:\Program Files`, true},
+		// dwords and binary values are not strings
+		{`software\delphine software\darkstone`, "version", "", false},
+		{`software\wow6432node\delphinesoft\darkstone\currentversion\darkstone`, "registered", "", false},
+		{`software\delphinesoft\darkstone`, "datapath", "", false},
+	}
+	for _, test := range tests {
+		value, ok := values[test.key][test.name]
+		if ok != test.ok || value != test.want {
+			t.Errorf("[%s] %s = %q, %v, want %q, %v", test.key, test.name, value, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestParseWineRegistryMalformed(t *testing.T) {
+	values, err := parseWineRegistry(strings.NewReader(strings.Join([]string{
+		`"Orphan"="before any key"`,
+		`[Software\\Broken`,
+		`"Lost"="key never closed"`,
+		`[Software\\Fine] 1`,
+		`"Unterminated"="C:\\Games`,
+		`"NoValue"`,
+		`"Odd"=hex(2):43,00,3a`,
+		`"NotHex"=hex(2):zz,00`,
+		`"Good"="yes"`,
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 1 {
+		t.Fatalf("keys = %v, want only software\\fine", values)
+	}
+	fine := values[`software\fine`]
+	if len(fine) != 1 || fine["good"] != "yes" {
+		t.Errorf(`software\fine = %v, want only good=yes`, fine)
+	}
+}
+
+// a prefix laid out like wine does it, with c: linked to drive_c and d: to a directory outside the prefix
+func testWineprefix(t *testing.T) (string, string) {
+	t.Helper()
+
+	prefix := t.TempDir()
+	otherDrive := t.TempDir()
+	for _, directory := range []string{
+		filepath.Join(prefix, "dosdevices"),
+		filepath.Join(prefix, "drive_c", "GOG Games", "Darkstone"),
+		filepath.Join(otherDrive, "Darkstone \"Gold\""),
+	} {
+		err := os.MkdirAll(directory, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := os.Symlink(filepath.Join("..", "drive_c"), filepath.Join(prefix, "dosdevices", "c:"))
+	if err != nil {
+		t.Skipf("can not create symlinks: %v", err)
+	}
+	err = os.Symlink(otherDrive, filepath.Join(prefix, "dosdevices", "d:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return prefix, otherDrive
+}
+
+func evalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolvedPath
+}
+
+func TestTranslateWinePath(t *testing.T) {
+	prefix, otherDrive := testWineprefix(t)
+	bare := t.TempDir()
+	missing := filepath.Join(bare, "missing")
+
+	tests := []struct {
+		name        string
+		prefix      string
+		windowsPath string
+		want        string
+	}{
+		{"c: through dosdevices", prefix, `C:\GOG Games\Darkstone`, evalSymlinks(t, filepath.Join(prefix, "drive_c", "GOG Games", "Darkstone"))},
+		{"lower case drive and trailing slash", prefix, `c:\GOG Games\Darkstone\`, evalSymlinks(t, filepath.Join(prefix, "drive_c", "GOG Games", "Darkstone"))},
+		{"d: outside the prefix", prefix, `D:\Darkstone "Gold"`, evalSymlinks(t, filepath.Join(otherDrive, "Darkstone \"Gold\""))},
+		{"path that does not exist yet", prefix, `D:\Nowhere`, filepath.Join(prefix, "dosdevices", "d:", "Nowhere")},
+		{"no dosdevices falls back to drive_c", bare, `C:\Games\Darkstone`, filepath.Join(bare, "drive_c", "Games", "Darkstone")},
+		{"missing prefix", missing, `C:\Games\Darkstone`, filepath.Join(missing, "drive_c", "Games", "Darkstone")},
+		{"only c: has a fallback", missing, `E:\Darkstone`, filepath.Join(missing, "dosdevices", "e:", "Darkstone")},
+		{"not a drive path", prefix, `Games\Darkstone`, filepath.Join("Games", "Darkstone")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := translateWinePath(test.prefix, test.windowsPath)
+			if got != test.want {
+				t.Errorf("translateWinePath(%s) = %s, want %s", test.windowsPath, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectWineprefix(t *testing.T) {
+	prefix, otherDrive := testWineprefix(t)
+	registry, err := os.ReadFile(filepath.Join("testdata", "system.reg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(prefix, "system.reg"), registry, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installs := detectWineprefix(prefix)
+	want := []string{
+		evalSymlinks(t, filepath.Join(otherDrive, "Darkstone \"Gold\"")),
+		evalSymlinks(t, filepath.Join(prefix, "drive_c", "GOG Games", "Darkstone")),
+	}
+	if len(installs) != len(want) {
+		t.Fatalf("found %v, want %v", installs, want)
+	}
+	for i, install := range installs {
+		if install.Path != want[i] || install.Source != "wine ("+prefix+")" {
+			t.Errorf("install %d = %+v, want %s from the prefix", i, install, want[i])
+		}
+	}
+
+	if installs := detectWineprefix(filepath.Join(prefix, "missing")); len(installs) != 0 {
+		t.Errorf("missing prefix found %v", installs)
+	}
+}
@@ -0,0 +1,223 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// bump when IndexedFile changes so old caches get rebuilt instead of misread
+const archiveIndexVersion = 1
+
+type IndexedFile struct {
+	FileName    string `json:"file_name"`
+	ArchivePath string `json:"-"` // filled in from the archive it was found in
+	Size        uint32 `json:"size"`
+	Crc         uint32 `json:"crc"`
+}
+
+type indexedArchive struct {
+	// an archive is only read again when either of these change
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+
+	Files []IndexedFile `json:"files"`
+}
+
+// every virtual file of every archive seen so far, cached on disk since reading them all takes a while
+type ArchiveIndex struct {
+	Version  int                        `json:"version"`
+	Archives map[string]*indexedArchive `json:"archives"` // keyed by archive path
+}
+
+func ArchiveIndexPath() (string, error) {
+	userCacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userCacheDirectory, "stone-tools", "archive_index.json"), nil
+}
+
+// a missing, unreadable or outdated cache just means starting from an empty index
+func LoadArchiveIndex(indexPath string) *ArchiveIndex {
+	index := &ArchiveIndex{Version: archiveIndexVersion, Archives: make(map[string]*indexedArchive)}
+
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return index
+	}
+
+	var cached ArchiveIndex
+	err = json.Unmarshal(data, &cached)
+	if err != nil || cached.Version != archiveIndexVersion || cached.Archives == nil {
+		return index
+	}
+
+	return &cached
+}
+
+func (x *ArchiveIndex) Save(indexPath string) error {
+	err := os.MkdirAll(filepath.Dir(indexPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(indexPath, data, 0o644)
+}
+
+// reads any archive that is new or changed since it was last indexed and forgets ones that are gone.
+// progress, when given, is called after each archive. returns whether anything changed along with
+// the archives that could not be read
+func (x *ArchiveIndex) Refresh(archivePaths []string, progress func(done, total int)) (bool, map[string]error) {
+	var mutex sync.Mutex
+	changed := false
+	failures := make(map[string]error)
+	done := 0
+
+	for archivePath := range x.Archives {
+		filePath, _, _ := SplitArchivePath(archivePath)
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			delete(x.Archives, archivePath)
+			changed = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, runtime.NumCPU())
+	for _, archivePath := range archivePaths {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			archive, err := x.refreshArchive(archivePath, &mutex)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				failures[archivePath] = err
+			} else if archive != nil {
+				x.Archives[archivePath] = archive
+				changed = true
+			}
+
+			done++
+			if progress != nil {
+				progress(done, len(archivePaths))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return changed, failures
+}
+
+// returns nil when the cached copy is still good
+func (x *ArchiveIndex) refreshArchive(archivePath string, mutex *sync.Mutex) (*indexedArchive, error) {
+	modTime, size, err := archiveStat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	cached := x.Archives[archivePath]
+	mutex.Unlock()
+	if cached != nil && cached.ModTime.Equal(modTime) && cached.Size == size {
+		return nil, nil
+	}
+
+	files, err := indexArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexedArchive{ModTime: modTime, Size: size, Files: files}, nil
+}
+
+// archives inside an iso use the image's modification time and their own size
+func archiveStat(archivePath string) (time.Time, int64, error) {
+	filePath, _, inIso := SplitArchivePath(archivePath)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if !inIso {
+		return info.ModTime(), info.Size(), nil
+	}
+
+	section, closer, err := OpenArchiveFile(archivePath)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	defer closer.Close()
+
+	return info.ModTime(), section.Size(), nil
+}
+
+func indexArchive(archivePath string) ([]IndexedFile, error) {
+	mtfFile, closer, err := OpenArchiveFile(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	archive, err := ScanMtfFile(mtfFile)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]IndexedFile, 0, len(archive.VirtualFiles))
+	for _, virtualFile := range archive.VirtualFiles {
+		compressionInfo, err := ReadCompressionInfo(mtfFile, virtualFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// only compressed entries store a crc, work the rest out the same way so they can be compared
+		crc := compressionInfo.Crc
+		if !compressionInfo.Compressed {
+			data, err := ExtractVirtualFile(mtfFile, virtualFile)
+			if err != nil {
+				return nil, err
+			}
+			crc = CRC32(bytes.NewReader(data), uint64(len(data)))
+		}
+
+		files = append(files, IndexedFile{FileName: virtualFile.FileName, Size: virtualFile.TotalSize, Crc: crc})
+	}
+
+	return files, nil
+}
+
+// the indexed files of the given archives, ordered by archive then by their order inside it
+func (x *ArchiveIndex) Files(archivePaths []string) []IndexedFile {
+	sortedPaths := append([]string{}, archivePaths...)
+	sort.Strings(sortedPaths)
+
+	files := make([]IndexedFile, 0)
+	for _, archivePath := range sortedPaths {
+		archive, ok := x.Archives[archivePath]
+		if !ok {
+			continue
+		}
+
+		for _, file := range archive.Files {
+			file.ArchivePath = archivePath
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
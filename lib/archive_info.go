@@ -0,0 +1,96 @@
+package lib
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"stone-tools/lib/iso9660"
+	"time"
+)
+
+type ArchiveInfo struct {
+	Entries           int
+	CompressedEntries int
+	Size              int64
+	ModTime           time.Time
+	Sha1              string
+}
+
+// the share of entries that are compressed, 0 to 1
+func (i ArchiveInfo) CompressedShare() float64 {
+	if i.Entries == 0 {
+		return 0
+	}
+
+	return float64(i.CompressedEntries) / float64(i.Entries)
+}
+
+// reads the whole archive for the hash, so it takes as long as the archive is big
+func ReadArchiveInfo(archivePath string) (ArchiveInfo, error) {
+	mtfFile, closer, err := OpenArchiveFile(archivePath)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+	defer closer.Close()
+
+	info := ArchiveInfo{Size: mtfFile.Size()}
+	info.ModTime, err = archiveModTime(archivePath)
+	if err != nil {
+		return info, err
+	}
+
+	archive, err := ScanMtfFile(mtfFile)
+	if err != nil {
+		return info, err
+	}
+	info.Entries = len(archive.VirtualFiles)
+	for _, virtualFile := range archive.VirtualFiles {
+		compressionInfo, err := ReadCompressionInfo(mtfFile, virtualFile)
+		if err != nil {
+			return info, err
+		}
+		if compressionInfo.Compressed {
+			info.CompressedEntries++
+		}
+	}
+
+	hash := sha1.New()
+	_, err = io.Copy(hash, io.NewSectionReader(mtfFile, 0, mtfFile.Size()))
+	if err != nil {
+		return info, err
+	}
+	info.Sha1 = hex.EncodeToString(hash.Sum(nil))
+
+	return info, nil
+}
+
+// archives on a disc carry the time they were recorded, loose ones the file system's
+func archiveModTime(archivePath string) (time.Time, error) {
+	filePath, innerPath, inIso := SplitArchivePath(archivePath)
+	if !inIso {
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return stat.ModTime(), nil
+	}
+
+	isoFile, err := os.Open(filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer isoFile.Close()
+
+	image, err := iso9660.Open(isoFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	file, err := image.Find(innerPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return file.ModTime, nil
+}
@@ -0,0 +1,135 @@
+package lib
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"stone-tools/lib/iso9660"
+	"strings"
+)
+
+// archives inside a cd image are addressed as <iso path>!/<path inside the iso>
+const IsoPathSeparator = "!/"
+
+func IsoArchivePath(isoPath, innerPath string) string {
+	return isoPath + IsoPathSeparator + innerPath
+}
+
+func SplitArchivePath(archivePath string) (string, string, bool) {
+	isoPath, innerPath, found := strings.Cut(archivePath, IsoPathSeparator)
+	if !found || !strings.EqualFold(path.Ext(strings.ReplaceAll(isoPath, "\\", "/")), ".iso") {
+		return archivePath, "", false
+	}
+
+	return isoPath, innerPath, true
+}
+
+// opens an archive either straight from disk or from inside an iso, the closer releases the underlying file
+func OpenArchiveFile(archivePath string) (*io.SectionReader, io.Closer, error) {
+	filePath, innerPath, inIso := SplitArchivePath(archivePath)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !inIso {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+
+		return io.NewSectionReader(file, 0, info.Size()), file, nil
+	}
+
+	image, err := iso9660.Open(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	isoFile, err := image.Find(innerPath)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return image.Open(isoFile), file, nil
+}
+
+func ReadArchiveFile(archivePath string) ([]byte, error) {
+	section, closer, err := OpenArchiveFile(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	data := make([]byte, section.Size())
+	_, err = io.ReadFull(section, data)
+	return data, err
+}
+
+// every .mtf archive on the disc, as archive paths OpenArchiveFile understands
+func ListIsoArchives(isoPath string) ([]string, error) {
+	isoFile, err := os.Open(isoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer isoFile.Close()
+
+	image, err := iso9660.Open(isoFile)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := image.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	archivePaths := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir && strings.EqualFold(path.Ext(file.Path), ".mtf") {
+			archivePaths = append(archivePaths, IsoArchivePath(isoPath, file.Path))
+		}
+	}
+
+	return archivePaths, nil
+}
+
+// every archive under root, loose .mtf files and the ones inside .iso images alike, unreadable images are skipped
+func FindArchives(root string) []string {
+	archivePaths, _ := FindArchivesContext(context.Background(), root)
+	return archivePaths
+}
+
+// like FindArchives but gives up once ctx is done, for directories that may hold a whole disk
+func FindArchivesContext(ctx context.Context, root string) ([]string, error) {
+	archivePaths := make([]string, 0)
+	err := filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(d.Name())) {
+		case ".mtf":
+			archivePaths = append(archivePaths, filePath)
+		case ".iso":
+			isoArchivePaths, _ := ListIsoArchives(filePath)
+			archivePaths = append(archivePaths, isoArchivePaths...)
+		}
+		return nil
+	})
+
+	return archivePaths, err
+}
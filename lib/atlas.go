@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"slices"
+)
+
+type TextureAtlas struct {
+	Image   *image.NRGBA
+	Regions map[uint16]image.Rectangle
+}
+
+// packs the textures into rows, tallest first, growing the atlas width until it is roughly square
+func BuildTextureAtlas(textures map[uint16]image.Image, padding int) (TextureAtlas, error) {
+	if len(textures) == 0 {
+		return TextureAtlas{}, fmt.Errorf("no textures to pack")
+	}
+
+	materialIds := make([]uint16, 0, len(textures))
+	totalArea := 0
+	widest := 0
+	for materialId, texture := range textures {
+		materialIds = append(materialIds, materialId)
+		bounds := texture.Bounds()
+		totalArea += (bounds.Dx() + padding) * (bounds.Dy() + padding)
+		widest = max(widest, bounds.Dx()+padding)
+	}
+	slices.SortFunc(materialIds, func(a, b uint16) int {
+		heightA, heightB := textures[a].Bounds().Dy(), textures[b].Bounds().Dy()
+		if heightA != heightB {
+			return heightB - heightA
+		}
+		return int(a) - int(b)
+	})
+
+	atlasWidth := widest
+	for atlasWidth*atlasWidth < totalArea {
+		atlasWidth *= 2
+	}
+
+	regions := make(map[uint16]image.Rectangle)
+	x, y, rowHeight := 0, 0, 0
+	for _, materialId := range materialIds {
+		bounds := textures[materialId].Bounds()
+		if x+bounds.Dx() > atlasWidth {
+			x = 0
+			y += rowHeight + padding
+			rowHeight = 0
+		}
+
+		regions[materialId] = image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy())
+		x += bounds.Dx() + padding
+		rowHeight = max(rowHeight, bounds.Dy())
+	}
+
+	atlas := image.NewNRGBA(image.Rect(0, 0, atlasWidth, y+rowHeight))
+	for materialId, region := range regions {
+		texture := textures[materialId]
+		draw.Draw(atlas, region, texture, texture.Bounds().Min, draw.Src)
+	}
+
+	return TextureAtlas{
+		Image:   atlas,
+		Regions: regions,
+	}, nil
+}
+
+// points every face at the atlas material and squeezes its uvs into the region its old texture was packed into.
+// uvs outside of 0..1 relied on texture wrapping which an atlas can't do, those are clamped and counted
+func (a TextureAtlas) RemapO3D(o3dModel O3DModel, atlasMaterialId uint16) (O3DModel, int) {
+	remapped := o3dModel
+	remapped.Faces = slices.Clone(o3dModel.Faces)
+
+	atlasWidth := a.Image.Bounds().Dx()
+	atlasHeight := a.Image.Bounds().Dy()
+	clamped := 0
+	remap := func(value float32, offset, size, atlasSize int) float32 {
+		uv := DecodeO3DTexCoord(value)
+		if uv < 0 || uv > 1 {
+			clamped++
+			uv = min(max(uv, 0), 1)
+		}
+
+		return EncodeO3DTexCoord((float32(offset) + uv*float32(size)) / float32(atlasSize))
+	}
+
+	for i, face := range remapped.Faces {
+		region, ok := a.Regions[face.MaterialId]
+		if !ok {
+			continue
+		}
+
+		texCoords := [][2]*float32{{&face.Tx0, &face.Ty0}, {&face.Tx1, &face.Ty1}, {&face.Tx2, &face.Ty2}}
+		if face.V3 != O3DUnused {
+			texCoords = append(texCoords, [2]*float32{&face.Tx3, &face.Ty3})
+		}
+		for _, texCoord := range texCoords {
+			*texCoord[0] = remap(*texCoord[0], region.Min.X, region.Dx(), atlasWidth)
+			*texCoord[1] = remap(*texCoord[1], region.Min.Y, region.Dy(), atlasHeight)
+		}
+
+		face.MaterialId = atlasMaterialId
+		remapped.Faces[i] = face
+	}
+
+	return remapped, clamped
+}
@@ -0,0 +1,31 @@
+package lib
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRemapO3DKeepsUnpackedMaterials(t *testing.T) {
+	o3dModel := testO3DModel()
+
+	// only the quad's texture made it into the atlas
+	atlas, err := BuildTextureAtlas(map[uint16]image.Image{1234: image.NewNRGBA(image.Rect(0, 0, 8, 8))}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remapped, clamped := atlas.RemapO3D(o3dModel, 1234)
+	if clamped != 0 {
+		t.Errorf("clamped %d uvs, want none", clamped)
+	}
+
+	if remapped.Faces[0].MaterialId != 1234 {
+		t.Errorf("packed face material = %d, want the atlas material", remapped.Faces[0].MaterialId)
+	}
+	if remapped.Faces[1] != o3dModel.Faces[1] {
+		t.Errorf("unpacked face changed: %+v, want %+v", remapped.Faces[1], o3dModel.Faces[1])
+	}
+	if o3dModel.Faces[0] != testO3DModel().Faces[0] {
+		t.Error("remapping changed the source model")
+	}
+}
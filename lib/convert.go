@@ -0,0 +1,214 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"stone-tools/lib/tga"
+	"strings"
+	"unicode/utf8"
+)
+
+// a post-extraction step that turns a raw game file into something friendlier to work with
+type Converter struct {
+	Name            string
+	Description     string
+	Extensions      []string // lower case, including the dot
+	OutputExtension string   // empty keeps the source file's extension
+	Convert         func(fileName string, data []byte) ([]byte, error)
+}
+
+// what to do when an extracted file is already on disk
+type OverwritePolicy string
+
+const (
+	OverwriteAlways OverwritePolicy = "overwrite"
+	OverwriteSkip   OverwritePolicy = "skip"
+	OverwriteRename OverwritePolicy = "rename" // keep both, the new file gets a ` (n)` suffix
+)
+
+var OverwritePolicies = []OverwritePolicy{OverwriteAlways, OverwriteSkip, OverwriteRename}
+
+type ConvertOptions struct {
+	Converters []Converter
+	Replace    bool            // write only the converted file instead of keeping the raw one next to it
+	Overwrite  OverwritePolicy // empty behaves like OverwriteAlways
+}
+
+var TgaToPngConverter = Converter{
+	Name:            "png",
+	Description:     "TGA to PNG",
+	Extensions:      []string{".tga"},
+	OutputExtension: ".png",
+	Convert: func(fileName string, data []byte) ([]byte, error) {
+		tgaImage, err := tga.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var pngData bytes.Buffer
+		err = png.Encode(&pngData, tgaImage)
+		return pngData.Bytes(), err
+	},
+}
+
+// textures live in other archives so extracted models come out untextured
+var O3DToGlbConverter = Converter{
+	Name:            "gltf",
+	Description:     "O3D to glTF",
+	Extensions:      []string{".o3d"},
+	OutputExtension: ".glb",
+	Convert: func(fileName string, data []byte) ([]byte, error) {
+		o3dModel, err := ExtractO3D(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var glbData bytes.Buffer
+		err = WriteGlb(&glbData, o3dModel, strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)), nil)
+		return glbData.Bytes(), err
+	},
+}
+
+var TextToUtf8Converter = Converter{
+	Name:            "utf8",
+	Description:     "Text to UTF-8",
+	Extensions:      []string{".txt", ".ini", ".cfg", ".csv"},
+	OutputExtension: "",
+	Convert: func(fileName string, data []byte) ([]byte, error) {
+		if utf8.Valid(data) {
+			// already fine (plain ascii included), leave it be
+			return data, nil
+		}
+
+		return []byte(DecodeCP1252(data)), nil
+	},
+}
+
+var Converters = []Converter{
+	TgaToPngConverter,
+	O3DToGlbConverter,
+	TextToUtf8Converter,
+}
+
+func ConverterByName(name string) (Converter, bool) {
+	for _, converter := range Converters {
+		if converter.Name == name {
+			return converter, true
+		}
+	}
+
+	return Converter{}, false
+}
+
+func (o ConvertOptions) converterFor(fileName string) (Converter, bool) {
+	extension := strings.ToLower(filepath.Ext(fileName))
+	for _, converter := range o.Converters {
+		for _, converterExtension := range converter.Extensions {
+			if converterExtension == extension {
+				return converter, true
+			}
+		}
+	}
+
+	return Converter{}, false
+}
+
+// where the converted copy of writePath ends up. converters that keep the extension write over the
+// raw file when replacing it, otherwise next to it with a marker, e.g. FOO.utf8.ini, so FOO.INI and FOO.TXT never meet
+func (o ConvertOptions) outputPath(writePath string, converter Converter) string {
+	extension := filepath.Ext(writePath)
+	if converter.OutputExtension != "" {
+		return strings.TrimSuffix(writePath, extension) + converter.OutputExtension
+	}
+	if o.Replace {
+		return writePath
+	}
+
+	return strings.TrimSuffix(writePath, extension) + "." + converter.Name + extension
+}
+
+// writes an extracted file to disk along with any conversion that applies, returning every path written
+func WriteExtractedFile(writePath string, data []byte, convertOptions ConvertOptions) ([]string, error) {
+	err := os.MkdirAll(filepath.Dir(writePath), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	writtenPaths := make([]string, 0, 2)
+	converter, hasConverter := convertOptions.converterFor(writePath)
+	if !hasConverter || !convertOptions.Replace {
+		writtenPath, err := convertOptions.writeFile(writePath, data)
+		if err != nil {
+			return writtenPaths, err
+		}
+		if writtenPath != "" {
+			writtenPaths = append(writtenPaths, writtenPath)
+		}
+	}
+
+	if !hasConverter {
+		return writtenPaths, nil
+	}
+
+	convertedPath := convertOptions.outputPath(writePath, converter)
+	if convertOptions.Overwrite == OverwriteSkip && fileExists(convertedPath) {
+		// no point converting something that will not be written
+		return writtenPaths, nil
+	}
+
+	convertedData, err := converter.Convert(writePath, data)
+	if err != nil {
+		if convertOptions.Replace {
+			// don't lose the file just because it would not convert
+			writtenPath, _ := convertOptions.writeFile(writePath, data)
+			if writtenPath != "" {
+				writtenPaths = append(writtenPaths, writtenPath)
+			}
+		}
+		return writtenPaths, fmt.Errorf("%s conversion failed: %w", converter.Description, err)
+	}
+
+	writtenPath, err := convertOptions.writeFile(convertedPath, convertedData)
+	if err != nil {
+		return writtenPaths, err
+	}
+	if writtenPath != "" {
+		writtenPaths = append(writtenPaths, writtenPath)
+	}
+
+	return writtenPaths, nil
+}
+
+// writes following the overwrite policy, returning where the data went or an empty path when it was skipped
+func (o ConvertOptions) writeFile(writePath string, data []byte) (string, error) {
+	if fileExists(writePath) {
+		switch o.Overwrite {
+		case OverwriteSkip:
+			return "", nil
+		case OverwriteRename:
+			writePath = availablePath(writePath)
+		}
+	}
+
+	return writePath, os.WriteFile(writePath, data, os.ModePerm)
+}
+
+// the first of `name (1).ext`, `name (2).ext`... that is not taken yet
+func availablePath(writePath string) string {
+	extension := filepath.Ext(writePath)
+	base := strings.TrimSuffix(writePath, extension)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, extension)
+		if !fileExists(candidate) {
+			return candidate
+		}
+	}
+}
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
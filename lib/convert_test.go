@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestConvertOutputPath(t *testing.T) {
+	tests := []struct {
+		writePath string
+		converter Converter
+		replace   bool
+		want      string
+	}{
+		{"DATA/FOO.INI", TextToUtf8Converter, false, "DATA/FOO.utf8.INI"},
+		{"DATA/FOO.INI", TextToUtf8Converter, true, "DATA/FOO.INI"},
+		{"DATA/FOO.TXT", TextToUtf8Converter, false, "DATA/FOO.utf8.TXT"},
+		{"DATA/FOO.csv", TextToUtf8Converter, false, "DATA/FOO.utf8.csv"},
+		{"DATA/K0001.TGA", TgaToPngConverter, false, "DATA/K0001.png"},
+		{"DATA/K0001.TGA", TgaToPngConverter, true, "DATA/K0001.png"},
+		{"DATA/TORCHE.O3D", O3DToGlbConverter, false, "DATA/TORCHE.glb"},
+	}
+	for _, test := range tests {
+		options := ConvertOptions{Converters: Converters, Replace: test.replace}
+		got := options.outputPath(filepath.FromSlash(test.writePath), test.converter)
+		if got != filepath.FromSlash(test.want) {
+			t.Errorf("outputPath(%s, %s, replace=%v) = %s, want %s", test.writePath, test.converter.Name, test.replace, got, test.want)
+		}
+	}
+}
+
+func TestWriteExtractedTextKeepsTypes(t *testing.T) {
+	directory := t.TempDir()
+	options := ConvertOptions{Converters: []Converter{TextToUtf8Converter}}
+
+	// cp1252 e acute, so the conversion actually changes something
+	files := map[string][]byte{
+		"FOO.INI": []byte("name=caf\xe9\r\n"),
+		"FOO.TXT": []byte("plain text\r\n"),
+		"FOO.CFG": []byte("x=1\r\n"),
+	}
+	for name, data := range files {
+		_, err := WriteExtractedFile(filepath.Join(directory, name), data, options)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	want := []string{"FOO.CFG", "FOO.INI", "FOO.TXT", "FOO.utf8.CFG", "FOO.utf8.INI", "FOO.utf8.TXT"}
+	if len(names) != len(want) {
+		t.Fatalf("wrote %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("wrote %v, want %v", names, want)
+		}
+	}
+
+	converted, err := os.ReadFile(filepath.Join(directory, "FOO.utf8.INI"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(converted) != "name=café\r\n" {
+		t.Errorf("converted ini is %q", converted)
+	}
+}
+
+func TestWriteExtractedTextReplace(t *testing.T) {
+	directory := t.TempDir()
+	options := ConvertOptions{Converters: []Converter{TextToUtf8Converter}, Replace: true}
+
+	writePath := filepath.Join(directory, "FOO.INI")
+	written, err := WriteExtractedFile(writePath, []byte("caf\xe9"), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || written[0] != writePath {
+		t.Fatalf("wrote %v, want only %s", written, writePath)
+	}
+
+	data, err := os.ReadFile(writePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "café" {
+		t.Errorf("replaced file holds %q", data)
+	}
+}
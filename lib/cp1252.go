@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// windows-1252 is latin-1 except for 0x80-0x9F, zeros are the five undefined bytes
+var cp1252Table = [32]rune{
+	0x20AC, 0x0000, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x0000, 0x017D, 0x0000,
+	0x0000, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x0000, 0x017E, 0x0178,
+}
+
+// decodes the game's windows-1252 text (french accents and all) into a go string
+func DecodeCP1252(data []byte) string {
+	var builder strings.Builder
+	builder.Grow(len(data))
+	for _, b := range data {
+		switch {
+		case b < 0x80 || b >= 0xA0:
+			builder.WriteRune(rune(b))
+		case cp1252Table[b-0x80] != 0:
+			builder.WriteRune(cp1252Table[b-0x80])
+		default:
+			builder.WriteRune(utf8.RuneError)
+		}
+	}
+
+	return builder.String()
+}
+
+// the reverse of DecodeCP1252, runes windows-1252 has no byte for come back as false
+func EncodeCP1252(s string) ([]byte, bool) {
+	data := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			data = append(data, byte(r))
+		default:
+			index := -1
+			for i, tableRune := range cp1252Table {
+				if tableRune == r && r != 0 {
+					index = i
+				}
+			}
+			if index < 0 {
+				return data, false
+			}
+			data = append(data, byte(0x80+index))
+		}
+	}
+
+	return data, true
+}
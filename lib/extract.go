@@ -2,17 +2,16 @@ package lib
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 )
 
-func ExtractAllFiles(mtfFilePath string) {
-	mtfFile, err := os.Open(mtfFilePath)
+func ExtractAllFiles(mtfFilePath, outputDirectory string, convertOptions ConvertOptions) {
+	mtfFile, closer, err := OpenArchiveFile(mtfFilePath)
 	if err != nil {
 		fmt.Println("Error opening file:", err)
 		return
 	}
-	defer mtfFile.Close()
+	defer closer.Close()
 
 	archive, err := ScanMtfFile(mtfFile)
 	if err != nil {
@@ -27,11 +26,10 @@ func ExtractAllFiles(mtfFilePath string) {
 			continue
 		}
 
-		writePath := filepath.Join("out", virtualFile.FileName)
+		writePath := filepath.Join(outputDirectory, virtualFile.FileName)
 		fmt.Printf("Writing `%s` (%d bytes)...\r\n", writePath, len(extractedFile))
 
-		os.MkdirAll(filepath.Dir(writePath), os.ModePerm)
-		err = os.WriteFile(writePath, extractedFile, os.ModePerm)
+		_, err = WriteExtractedFile(writePath, extractedFile, convertOptions)
 		if err != nil {
 			fmt.Printf("Error writing extracted file `%s`: %+v\r\n", virtualFile.FileName, err)
 			continue
@@ -0,0 +1,349 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+const (
+	glbMagic     uint32 = 0x46546C67 // "glTF"
+	glbVersion   uint32 = 2
+	glbChunkJson uint32 = 0x4E4F534A // "JSON"
+	glbChunkBin  uint32 = 0x004E4942 // "BIN\0"
+
+	gltfFloat         = 5126
+	gltfUnsignedByte  = 5121
+	gltfUnsignedInt   = 5125
+	gltfArrayBuffer   = 34962
+	gltfElementBuffer = 34963
+	gltfLinear        = 9729
+	gltfRepeat        = 10497
+	gltfTriangles     = 4
+)
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Samplers    []gltfSampler    `json:"samplers,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name string `json:"name,omitempty"`
+	Mesh int    `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfMaterial struct {
+	Name                 string                   `json:"name"`
+	PbrMetallicRoughness gltfPbrMetallicRoughness `json:"pbrMetallicRoughness"`
+	DoubleSided          bool                     `json:"doubleSided"`
+}
+
+type gltfPbrMetallicRoughness struct {
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float32          `json:"metallicFactor"`
+	RoughnessFactor  float32          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Sampler int `json:"sampler"`
+	Source  int `json:"source"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name,omitempty"`
+	BufferView int    `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Normalized    bool      `json:"normalized,omitempty"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+type gltfBuilder struct {
+	document gltfDocument
+	binary   bytes.Buffer
+}
+
+// appends data to the binary chunk as its own buffer view, keeping everything 4 byte aligned
+func (b *gltfBuilder) addBufferView(data []byte, target int) int {
+	b.document.BufferViews = append(b.document.BufferViews, gltfBufferView{
+		Buffer:     0,
+		ByteOffset: b.binary.Len(),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	b.binary.Write(data)
+	for b.binary.Len()%4 != 0 {
+		b.binary.WriteByte(0)
+	}
+
+	return len(b.document.BufferViews) - 1
+}
+
+func (b *gltfBuilder) addAccessor(accessor gltfAccessor) int {
+	b.document.Accessors = append(b.document.Accessors, accessor)
+	return len(b.document.Accessors) - 1
+}
+
+func (b *gltfBuilder) addTexture(name string, texture image.Image) (int, error) {
+	var pngData bytes.Buffer
+	err := png.Encode(&pngData, texture)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b.document.Samplers) == 0 {
+		b.document.Samplers = append(b.document.Samplers, gltfSampler{
+			MagFilter: gltfLinear,
+			MinFilter: gltfLinear,
+			WrapS:     gltfRepeat,
+			WrapT:     gltfRepeat,
+		})
+	}
+
+	b.document.Images = append(b.document.Images, gltfImage{
+		Name:       name,
+		BufferView: b.addBufferView(pngData.Bytes(), 0),
+		MimeType:   "image/png",
+	})
+	b.document.Textures = append(b.document.Textures, gltfTexture{
+		Sampler: 0,
+		Source:  len(b.document.Images) - 1,
+	})
+
+	return len(b.document.Textures) - 1, nil
+}
+
+func (b *gltfBuilder) addPrimitive(o3dModel O3DModel, materialId uint16, material int) gltfPrimitive {
+	positions := make([]float32, 0)
+	texCoords := make([]float32, 0)
+	colors := make([]uint8, 0)
+	indices := make([]uint32, 0)
+
+	minPosition := []float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	maxPosition := []float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+
+	addCorner := func(face O3DFace, vertexIndex uint16, tx, ty float32) uint32 {
+		vertex := o3dModel.Vertices[vertexIndex]
+		positions = append(positions, vertex.X, vertex.Y, vertex.Z)
+		texCoords = append(texCoords, DecodeO3DTexCoord(tx), DecodeO3DTexCoord(ty))
+		// same channel order the model viewer uses
+		colors = append(colors, face.MaybeBlue, face.MaybeGreen, face.MaybeRed, face.MaybeAlpha)
+
+		for i, value := range []float32{vertex.X, vertex.Y, vertex.Z} {
+			minPosition[i] = min(minPosition[i], value)
+			maxPosition[i] = max(maxPosition[i], value)
+		}
+
+		return uint32(len(positions)/3 - 1)
+	}
+
+	for _, face := range o3dModel.Faces {
+		if face.MaterialId != materialId {
+			continue
+		}
+
+		corner0 := addCorner(face, face.V0, face.Tx0, face.Ty0)
+		corner1 := addCorner(face, face.V1, face.Tx1, face.Ty1)
+		corner2 := addCorner(face, face.V2, face.Tx2, face.Ty2)
+		indices = append(indices, corner0, corner1, corner2)
+
+		if face.V3 != O3DUnused {
+			corner3 := addCorner(face, face.V3, face.Tx3, face.Ty3)
+			indices = append(indices, corner2, corner3, corner0)
+		}
+	}
+
+	vertexCount := len(positions) / 3
+	positionAccessor := b.addAccessor(gltfAccessor{
+		BufferView:    b.addBufferView(littleEndianBytes(positions), gltfArrayBuffer),
+		ComponentType: gltfFloat,
+		Count:         vertexCount,
+		Type:          "VEC3",
+		Min:           minPosition,
+		Max:           maxPosition,
+	})
+	texCoordAccessor := b.addAccessor(gltfAccessor{
+		BufferView:    b.addBufferView(littleEndianBytes(texCoords), gltfArrayBuffer),
+		ComponentType: gltfFloat,
+		Count:         vertexCount,
+		Type:          "VEC2",
+	})
+	colorAccessor := b.addAccessor(gltfAccessor{
+		BufferView:    b.addBufferView(colors, gltfArrayBuffer),
+		ComponentType: gltfUnsignedByte,
+		Normalized:    true,
+		Count:         vertexCount,
+		Type:          "VEC4",
+	})
+	indexAccessor := b.addAccessor(gltfAccessor{
+		BufferView:    b.addBufferView(littleEndianBytes(indices), gltfElementBuffer),
+		ComponentType: gltfUnsignedInt,
+		Count:         len(indices),
+		Type:          "SCALAR",
+	})
+
+	return gltfPrimitive{
+		Attributes: map[string]int{
+			"POSITION":   positionAccessor,
+			"TEXCOORD_0": texCoordAccessor,
+			"COLOR_0":    colorAccessor,
+		},
+		Indices:  indexAccessor,
+		Material: material,
+		Mode:     gltfTriangles,
+	}
+}
+
+// textures maps a material id to the image to embed for it, materials without an entry are left untextured
+func WriteGlb(glbFile io.Writer, o3dModel O3DModel, name string, textures map[uint16]image.Image) error {
+	// a mesh needs at least one primitive and a buffer at least one byte, neither exists without faces
+	if len(o3dModel.Faces) == 0 {
+		return fmt.Errorf("model has no faces to export")
+	}
+	// checked up front so a broken model is an error instead of a panic halfway through building the buffers
+	for i, face := range o3dModel.Faces {
+		indexes := []uint16{face.V0, face.V1, face.V2}
+		if face.V3 != O3DUnused {
+			indexes = append(indexes, face.V3)
+		}
+		for _, index := range indexes {
+			if int(index) >= len(o3dModel.Vertices) {
+				return fmt.Errorf("face %d references vertex %d but the model only has %d vertices", i, index, len(o3dModel.Vertices))
+			}
+		}
+	}
+
+	builder := gltfBuilder{
+		document: gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: "stone-tools"},
+			Scene:  0,
+			Scenes: []gltfScene{{Nodes: []int{0}}},
+			Nodes:  []gltfNode{{Name: name, Mesh: 0}},
+		},
+	}
+
+	mesh := gltfMesh{Name: name, Primitives: make([]gltfPrimitive, 0)}
+	for _, materialId := range O3DMaterialIds(o3dModel) {
+		material := gltfMaterial{
+			Name: ObjMaterialName(materialId),
+			PbrMetallicRoughness: gltfPbrMetallicRoughness{
+				MetallicFactor:  0,
+				RoughnessFactor: 1,
+			},
+			DoubleSided: true,
+		}
+
+		if texture, ok := textures[materialId]; ok && texture != nil {
+			textureIndex, err := builder.addTexture(ObjMaterialName(materialId), texture)
+			if err != nil {
+				return err
+			}
+			material.PbrMetallicRoughness.BaseColorTexture = &gltfTextureInfo{Index: textureIndex}
+		}
+
+		builder.document.Materials = append(builder.document.Materials, material)
+		mesh.Primitives = append(mesh.Primitives, builder.addPrimitive(o3dModel, materialId, len(builder.document.Materials)-1))
+	}
+	builder.document.Meshes = []gltfMesh{mesh}
+	builder.document.Buffers = []gltfBuffer{{ByteLength: builder.binary.Len()}}
+
+	jsonData, err := json.Marshal(builder.document)
+	if err != nil {
+		return err
+	}
+	// json chunk is padded with spaces, binary chunk was already padded with zeros
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+
+	totalLength := 12 + 8 + len(jsonData) + 8 + builder.binary.Len()
+	header := []uint32{glbMagic, glbVersion, uint32(totalLength), uint32(len(jsonData)), glbChunkJson}
+	err = binary.Write(glbFile, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	_, err = glbFile.Write(jsonData)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(glbFile, binary.LittleEndian, []uint32{uint32(builder.binary.Len()), glbChunkBin})
+	if err != nil {
+		return err
+	}
+
+	_, err = glbFile.Write(builder.binary.Bytes())
+	return err
+}
+
+func littleEndianBytes(data any) []byte {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, data)
+	return buffer.Bytes()
+}
@@ -0,0 +1,185 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// splits a glb into its parsed json chunk and raw binary chunk, failing on anything malformed
+func readGlb(t *testing.T, data []byte) (gltfDocument, []byte) {
+	t.Helper()
+
+	if len(data) < 12 {
+		t.Fatalf("glb is only %d bytes", len(data))
+	}
+	var header [3]uint32
+	binary.Read(bytes.NewReader(data), binary.LittleEndian, &header)
+	if header[0] != glbMagic || header[1] != glbVersion {
+		t.Fatalf("bad glb header %#x version %d", header[0], header[1])
+	}
+	if int(header[2]) != len(data) {
+		t.Fatalf("header says %d bytes, file has %d", header[2], len(data))
+	}
+
+	chunks := make(map[uint32][]byte)
+	for offset := 12; offset < len(data); {
+		if offset+8 > len(data) {
+			t.Fatalf("chunk header at %d runs past the end", offset)
+		}
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		if length%4 != 0 {
+			t.Errorf("chunk %#x length %d is not 4 byte aligned", chunkType, length)
+		}
+		if offset+8+length > len(data) {
+			t.Fatalf("chunk %#x runs past the end", chunkType)
+		}
+		chunks[chunkType] = data[offset+8 : offset+8+length]
+		offset += 8 + length
+	}
+
+	var document gltfDocument
+	err := json.Unmarshal(chunks[glbChunkJson], &document)
+	if err != nil {
+		t.Fatalf("json chunk: %v", err)
+	}
+
+	return document, chunks[glbChunkBin]
+}
+
+func accessorData(t *testing.T, document gltfDocument, bin []byte, accessor gltfAccessor) []byte {
+	t.Helper()
+
+	componentSizes := map[int]int{gltfFloat: 4, gltfUnsignedInt: 4, gltfUnsignedByte: 1}
+	components := map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4}
+
+	view := document.BufferViews[accessor.BufferView]
+	size := accessor.Count * componentSizes[accessor.ComponentType] * components[accessor.Type]
+	if size != view.ByteLength {
+		t.Errorf("accessor needs %d bytes, its buffer view holds %d", size, view.ByteLength)
+	}
+
+	return bin[view.ByteOffset : view.ByteOffset+view.ByteLength]
+}
+
+func TestWriteGlbStructure(t *testing.T) {
+	model := testO3DModel()
+	texture := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	texture.Set(1, 1, color.NRGBA{R: 255, A: 255})
+
+	var glb bytes.Buffer
+	err := WriteGlb(&glb, model, "test", map[uint16]image.Image{1234: texture})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document, bin := readGlb(t, glb.Bytes())
+
+	if len(document.Buffers) != 1 || document.Buffers[0].ByteLength != len(bin) || len(bin) == 0 {
+		t.Fatalf("buffers %+v do not match a %d byte binary chunk", document.Buffers, len(bin))
+	}
+	for i, view := range document.BufferViews {
+		if view.ByteOffset < 0 || view.ByteLength <= 0 || view.ByteOffset+view.ByteLength > len(bin) {
+			t.Errorf("buffer view %d (%d+%d) is outside the %d byte binary chunk", i, view.ByteOffset, view.ByteLength, len(bin))
+		}
+	}
+	if len(document.Images) != 1 || len(document.Textures) != 1 {
+		t.Errorf("want one embedded texture, got %d images and %d textures", len(document.Images), len(document.Textures))
+	}
+
+	if len(document.Meshes) != 1 || len(document.Meshes[0].Primitives) != 2 {
+		t.Fatalf("want one mesh with a primitive per material, got %+v", document.Meshes)
+	}
+
+	// primitives follow the material ids in order, the triangle's 65 before the quad's 1234,
+	// the triangle is kept as is and the quad split into two
+	wantCorners := []int{3, 4}
+	wantIndices := []int{3, 6}
+	wantAlpha := []uint8{0x80, 0xFF}
+	for i, primitive := range document.Meshes[0].Primitives {
+		position := document.Accessors[primitive.Attributes["POSITION"]]
+		if position.Count != wantCorners[i] {
+			t.Errorf("primitive %d has %d corners, want %d", i, position.Count, wantCorners[i])
+		}
+		for _, attribute := range []string{"TEXCOORD_0", "COLOR_0"} {
+			if count := document.Accessors[primitive.Attributes[attribute]].Count; count != position.Count {
+				t.Errorf("primitive %d %s count %d, want %d", i, attribute, count, position.Count)
+			}
+		}
+
+		positions := make([]float32, position.Count*3)
+		binary.Read(bytes.NewReader(accessorData(t, document, bin, position)), binary.LittleEndian, positions)
+		for axis := 0; axis < 3; axis++ {
+			low, high := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+			for corner := 0; corner < position.Count; corner++ {
+				low = min(low, positions[corner*3+axis])
+				high = max(high, positions[corner*3+axis])
+			}
+			if position.Min[axis] != low || position.Max[axis] != high {
+				t.Errorf("primitive %d axis %d min/max %v/%v, data has %v/%v", i, axis, position.Min[axis], position.Max[axis], low, high)
+			}
+		}
+
+		indexAccessor := document.Accessors[primitive.Indices]
+		if indexAccessor.Count != wantIndices[i] {
+			t.Errorf("primitive %d has %d indices, want %d", i, indexAccessor.Count, wantIndices[i])
+		}
+		indices := make([]uint32, indexAccessor.Count)
+		binary.Read(bytes.NewReader(accessorData(t, document, bin, indexAccessor)), binary.LittleEndian, indices)
+		for _, index := range indices {
+			if int(index) >= position.Count {
+				t.Errorf("primitive %d index %d is past its %d corners", i, index, position.Count)
+			}
+		}
+
+		colors := accessorData(t, document, bin, document.Accessors[primitive.Attributes["COLOR_0"]])
+		if colors[3] != wantAlpha[i] {
+			t.Errorf("primitive %d alpha %d, want %d", i, colors[3], wantAlpha[i])
+		}
+	}
+}
+
+func TestWriteGlbWithoutFaces(t *testing.T) {
+	model := testO3DModel()
+	model.Faces = nil
+	model.NumberOfFaces = 0
+
+	var glb bytes.Buffer
+	err := WriteGlb(&glb, model, "empty", nil)
+	if err == nil {
+		t.Fatal("want an error for a model without faces")
+	}
+	if glb.Len() != 0 {
+		t.Errorf("wrote %d bytes for a model that cannot be exported", glb.Len())
+	}
+}
+
+func TestWriteGlbIndexOutOfRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(face *O3DFace)
+	}{
+		{"first corner", func(face *O3DFace) { face.V0 = 5 }},
+		{"fourth corner", func(face *O3DFace) { face.V3 = 200 }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := testO3DModel()
+			test.modify(&model.Faces[0])
+
+			var glb bytes.Buffer
+			err := WriteGlb(&glb, model, "broken", nil)
+			if err == nil {
+				t.Fatal("want an error for a face referencing a missing vertex")
+			}
+			if glb.Len() != 0 {
+				t.Errorf("wrote %d bytes for a model that cannot be exported", glb.Len())
+			}
+		})
+	}
+}
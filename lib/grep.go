@@ -0,0 +1,366 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type GrepMode string
+
+const (
+	GrepLiteral GrepMode = "literal" // text, matched against the windows-1252 bytes the game stores
+	GrepRegex   GrepMode = "regex"   // go regexp syntax run over the raw bytes, so best kept to ascii
+	GrepHex     GrepMode = "hex"     // bytes like `de ad be ef` or `deadbeef`
+)
+
+const defaultGrepContext = 24
+
+type GrepOptions struct {
+	Mode       GrepMode
+	Pattern    string
+	IgnoreCase bool // literal and regex only
+
+	ArchiveFilters []string // globs matched against archive file names, e.g. DATA*.MTF, empty for all
+	Extensions     []string // e.g. .txt or txt, empty for all
+
+	Workers            int // defaults to the number of cpus
+	ContextBytes       int // either side of a match, defaults to 24
+	MaxMatchesPerEntry int // 0 for no limit
+}
+
+type GrepMatch struct {
+	ArchivePath string
+	FileName    string
+	Offset      int
+
+	// the match with some of what surrounds it, raw bytes so callers can show them as text or hex
+	Before []byte
+	Match  []byte
+	After  []byte
+}
+
+type GrepStats struct {
+	Archives int
+	Entries  int
+	Bytes    int64
+	Errors   []error
+}
+
+// how a pattern is applied, everything compiles down to finding byte ranges
+type grepMatcher func(data []byte, limit int) [][]int
+
+type grepJob struct {
+	archivePath string
+	mtfFile     *io.SectionReader
+	virtualFile MtfVirtualFile
+	done        func() // lets the archive know one more of its entries is finished
+}
+
+// searches the decompressed contents of every matching entry, onMatch is never called concurrently.
+// entries are not streamed, each one is decompressed into memory whole and searched there, so a search
+// holds up to Workers entries at a time and matches never get split across reads.
+// returns ctx.Err() when canceled, along with whatever was searched up to then
+func Grep(ctx context.Context, archivePaths []string, options GrepOptions, onMatch func(GrepMatch)) (GrepStats, error) {
+	matcher, err := compileGrepPattern(options)
+	if err != nil {
+		return GrepStats{}, err
+	}
+
+	workerCount := options.Workers
+	if workerCount <= 0 {
+		workerCount = runtime.NumCPU()
+	}
+	contextBytes := options.ContextBytes
+	if contextBytes <= 0 {
+		contextBytes = defaultGrepContext
+	}
+
+	var stats GrepStats
+	var mutex sync.Mutex
+	recordError := func(err error) {
+		mutex.Lock()
+		stats.Errors = append(stats.Errors, err)
+		mutex.Unlock()
+	}
+
+	searchEntry := func(job grepJob) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// each worker gets its own reader over the shared file, ReadAt is safe to share
+		entryReader := io.NewSectionReader(job.mtfFile, 0, job.mtfFile.Size())
+		data, err := ExtractVirtualFile(entryReader, job.virtualFile)
+		if err != nil {
+			recordError(fmt.Errorf("%s: %s: %w", job.archivePath, job.virtualFile.FileName, err))
+			return
+		}
+
+		ranges := matcher(data, options.MaxMatchesPerEntry)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		stats.Entries++
+		stats.Bytes += int64(len(data))
+		for _, r := range ranges {
+			onMatch(GrepMatch{
+				ArchivePath: job.archivePath,
+				FileName:    job.virtualFile.FileName,
+				Offset:      r[0],
+				Before:      data[max(0, r[0]-contextBytes):r[0]],
+				Match:       data[r[0]:r[1]],
+				After:       data[r[1]:min(len(data), r[1]+contextBytes)],
+			})
+		}
+	}
+
+	jobs := make(chan grepJob)
+	var wg sync.WaitGroup
+	for range workerCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				searchEntry(job)
+				job.done()
+			}
+		}()
+	}
+
+	// an archive is closed as soon as its last entry has been searched instead of staying open until the end
+	var closing sync.WaitGroup
+	extensions := normalizeExtensions(options.Extensions)
+	queueArchive := func(archivePath string) bool {
+		mtfFile, closer, err := OpenArchiveFile(archivePath)
+		if err != nil {
+			recordError(fmt.Errorf("%s: %w", archivePath, err))
+			return true
+		}
+
+		// the close waits from the end of queueing on, before that its entries may not all be counted yet
+		var archiveJobs sync.WaitGroup
+		closing.Add(1)
+		defer func() {
+			go func() {
+				defer closing.Done()
+				archiveJobs.Wait()
+				closer.Close()
+			}()
+		}()
+
+		archive, err := ScanMtfFile(mtfFile)
+		if err != nil {
+			recordError(fmt.Errorf("%s: %w", archivePath, err))
+			return true
+		}
+
+		mutex.Lock()
+		stats.Archives++
+		mutex.Unlock()
+
+		for _, virtualFile := range archive.VirtualFiles {
+			if len(extensions) > 0 && !extensions[strings.ToLower(filepath.Ext(virtualFile.FileName))] {
+				continue
+			}
+
+			archiveJobs.Add(1)
+			select {
+			case jobs <- grepJob{archivePath: archivePath, mtfFile: mtfFile, virtualFile: virtualFile, done: archiveJobs.Done}:
+			case <-ctx.Done():
+				archiveJobs.Done()
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for _, archivePath := range archivePaths {
+		if !matchesArchiveFilters(archivePath, options.ArchiveFilters) {
+			continue
+		}
+		if !queueArchive(archivePath) {
+			break
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	closing.Wait()
+
+	return stats, ctx.Err()
+}
+
+func compileGrepPattern(options GrepOptions) (grepMatcher, error) {
+	if options.Pattern == "" {
+		return nil, fmt.Errorf("empty search pattern")
+	}
+
+	switch options.Mode {
+	case GrepLiteral, "":
+		needle, ok := EncodeCP1252(options.Pattern)
+		if !ok {
+			return nil, fmt.Errorf("`%s` has characters windows-1252 text cannot contain", options.Pattern)
+		}
+		if options.IgnoreCase {
+			return foldedLiteralMatcher(needle), nil
+		}
+		return literalMatcher(needle), nil
+	case GrepRegex:
+		expression := options.Pattern
+		if options.IgnoreCase {
+			expression = "(?i)" + expression
+		}
+		return compileGrepRegex(expression)
+	case GrepHex:
+		needle, err := ParseHexPattern(options.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return literalMatcher(needle), nil
+	}
+
+	return nil, fmt.Errorf("unknown search mode `%s`", options.Mode)
+}
+
+func compileGrepRegex(expression string) (grepMatcher, error) {
+	expr, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(data []byte, limit int) [][]int {
+		if limit <= 0 {
+			limit = -1
+		}
+		return expr.FindAllIndex(data, limit)
+	}, nil
+}
+
+func literalMatcher(needle []byte) grepMatcher {
+	return func(data []byte, limit int) [][]int {
+		ranges := make([][]int, 0)
+		for offset := 0; limit <= 0 || len(ranges) < limit; {
+			index := bytes.Index(data[offset:], needle)
+			if index < 0 {
+				break
+			}
+			ranges = append(ranges, []int{offset + index, offset + index + len(needle)})
+			offset += index + max(1, len(needle))
+		}
+		return ranges
+	}
+}
+
+// ascii case folding only, accented letters still have to match exactly
+func foldedLiteralMatcher(needle []byte) grepMatcher {
+	literal := literalMatcher(asciiLower(needle))
+	return func(data []byte, limit int) [][]int {
+		return literal(asciiLower(data), limit)
+	}
+}
+
+func asciiLower(data []byte) []byte {
+	lowered := make([]byte, len(data))
+	for i, b := range data {
+		if b >= 'A' && b <= 'Z' {
+			b += 'a' - 'A'
+		}
+		lowered[i] = b
+	}
+
+	return lowered
+}
+
+// accepts `deadbeef`, `de ad be ef`, `0xde 0xad` and the like
+func ParseHexPattern(pattern string) ([]byte, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == ',' {
+			return -1
+		}
+		return r
+	}, strings.ReplaceAll(strings.ToLower(pattern), "0x", ""))
+
+	data, err := hex.DecodeString(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("`%s` is not a hex byte pattern: %v", pattern, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty hex pattern")
+	}
+
+	return data, nil
+}
+
+func normalizeExtensions(extensions []string) map[string]bool {
+	normalized := make(map[string]bool)
+	for _, extension := range extensions {
+		extension = strings.ToLower(strings.TrimSpace(extension))
+		if extension == "" {
+			continue
+		}
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		normalized[extension] = true
+	}
+
+	return normalized
+}
+
+// archives inside an iso are matched by their own name
+func matchesArchiveFilters(archivePath string, archiveFilters []string) bool {
+	if len(archiveFilters) == 0 {
+		return true
+	}
+
+	name := filepath.Base(archivePath)
+	if _, innerPath, inIso := SplitArchivePath(archivePath); inIso {
+		name = filepath.Base(innerPath)
+	}
+
+	for _, archiveFilter := range archiveFilters {
+		if matched, _ := filepath.Match(strings.ToLower(archiveFilter), strings.ToLower(name)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the snippet as one line of text, control characters and undefined bytes shown as dots
+func (m GrepMatch) SnippetText() (string, string, string) {
+	printable := func(data []byte) string {
+		return strings.Map(func(r rune) rune {
+			if r == utf8.RuneError || unicode.IsControl(r) {
+				return '.'
+			}
+			return r
+		}, DecodeCP1252(data))
+	}
+
+	return printable(m.Before), printable(m.Match), printable(m.After)
+}
+
+// the snippet as hex bytes
+func (m GrepMatch) SnippetHex() (string, string, string) {
+	spaced := func(data []byte) string {
+		parts := make([]string, len(data))
+		for i, b := range data {
+			parts[i] = fmt.Sprintf("%02x", b)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	return spaced(m.Before), spaced(m.Match), spaced(m.After)
+}
@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGrepArchives(t *testing.T) {
+	root := t.TempDir()
+	archives := map[string][]testVirtualFile{
+		"DATA.MTF": {
+			{name: `DATA\A.TXT`, data: []byte("the torch is lit")},
+			{name: `DATA\B.INI`, data: []byte("TORCH=1")},
+		},
+		"MODEL.MTF": {
+			{name: `MESHES\TORCHE.TXT`, data: []byte("no match here")},
+			{name: `MESHES\C.TXT`, data: []byte("torch torch")},
+		},
+	}
+	archivePaths := make([]string, 0)
+	for name, files := range archives {
+		archivePath := filepath.Join(root, name)
+		err := os.WriteFile(archivePath, testMtf(files...), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archivePaths = append(archivePaths, archivePath)
+	}
+	archivePaths = append(archivePaths, filepath.Join(root, "MISSING.MTF"))
+
+	matches := make([]string, 0)
+	stats, err := Grep(context.Background(), archivePaths, GrepOptions{Pattern: "torch", IgnoreCase: true, Extensions: []string{"txt", ".ini"}, Workers: 2},
+		func(match GrepMatch) {
+			matches = append(matches, filepath.ToSlash(match.FileName)+":"+string(match.Match))
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(matches)
+	want := []string{"DATA/A.TXT:torch", "DATA/B.INI:TORCH", "MESHES/C.TXT:torch", "MESHES/C.TXT:torch"}
+	if !slices.Equal(matches, want) {
+		t.Errorf("matches = %v, want %v", matches, want)
+	}
+	if stats.Archives != 2 || stats.Entries != 4 || len(stats.Errors) != 1 {
+		t.Errorf("stats = %+v, want 2 archives, 4 entries and the missing archive as an error", stats)
+	}
+}
+
+func TestGrepCanceled(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "DATA.MTF")
+	err := os.WriteFile(archivePath, testMtf(testVirtualFile{name: "A.TXT", data: []byte("torch")}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Grep(ctx, []string{archivePath}, GrepOptions{Pattern: "torch"}, func(match GrepMatch) {
+		t.Errorf("matched %s after being canceled", match.FileName)
+	})
+	if err != context.Canceled {
+		t.Errorf("Grep() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,270 @@
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	SectorSize = 2048
+
+	volumeDescriptorStart  = 16
+	volumeTypePrimary      = 1
+	volumeTypeSupplemental = 2
+	volumeTypeTerminator   = 255
+
+	flagDirectory = 0x02
+
+	// far more than any real directory needs, a corrupt size must not turn into a 4GB allocation
+	maxDirectorySize = 16 * 1024 * 1024
+)
+
+var ErrNotIso = errors.New("iso9660: not an ISO 9660 image")
+
+type Image struct {
+	reader io.ReaderAt
+	root   directoryRecord
+	joliet bool
+}
+
+type File struct {
+	Path    string // slash separated, relative to the root of the image
+	Size    int64
+	IsDir   bool
+	ModTime time.Time // when it was recorded onto the image
+
+	extent uint32
+}
+
+type directoryRecord struct {
+	extent   uint32
+	size     uint32
+	recorded time.Time
+	flags    uint8
+	name     []byte
+}
+
+// reads the volume descriptors, using the joliet tree when there is one for its long and mixed case names
+func Open(reader io.ReaderAt) (*Image, error) {
+	image := &Image{reader: reader}
+
+	foundPrimary := false
+	descriptor := make([]byte, SectorSize)
+	for sector := int64(volumeDescriptorStart); ; sector++ {
+		_, err := reader.ReadAt(descriptor, sector*SectorSize)
+		if err != nil {
+			if foundPrimary {
+				break
+			}
+			return nil, ErrNotIso
+		}
+
+		if string(descriptor[1:6]) != "CD001" {
+			if foundPrimary {
+				break
+			}
+			return nil, ErrNotIso
+		}
+
+		switch descriptor[0] {
+		case volumeTypePrimary:
+			if !image.joliet {
+				image.root, err = parseDirectoryRecord(descriptor[156:190])
+			}
+			foundPrimary = true
+		case volumeTypeSupplemental:
+			if isJoliet(descriptor) {
+				image.root, err = parseDirectoryRecord(descriptor[156:190])
+				image.joliet = true
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("iso9660: corrupt root directory record in volume descriptor %d", sector)
+		}
+
+		if descriptor[0] == volumeTypeTerminator {
+			break
+		}
+	}
+
+	if !foundPrimary {
+		return nil, ErrNotIso
+	}
+
+	return image, nil
+}
+
+// joliet is a supplementary descriptor announcing UCS-2 level 1, 2 or 3 through its escape sequence
+func isJoliet(descriptor []byte) bool {
+	escape := descriptor[88:91]
+	return escape[0] == '%' && escape[1] == '/' && (escape[2] == '@' || escape[2] == 'C' || escape[2] == 'E')
+}
+
+var errCorruptRecord = errors.New("corrupt directory record")
+
+func parseDirectoryRecord(record []byte) (directoryRecord, error) {
+	if len(record) < 34 {
+		return directoryRecord{}, errCorruptRecord
+	}
+
+	nameLength := int(record[32])
+	if 33+nameLength > len(record) {
+		return directoryRecord{}, errCorruptRecord
+	}
+
+	return directoryRecord{
+		extent:   binary.LittleEndian.Uint32(record[2:6]),
+		size:     binary.LittleEndian.Uint32(record[10:14]),
+		recorded: parseRecordingTime(record[18:25]),
+		flags:    record[25],
+		name:     record[33 : 33+nameLength],
+	}, nil
+}
+
+// years since 1900, month, day, hour, minute, second and the offset from gmt in 15 minute steps
+func parseRecordingTime(date []byte) time.Time {
+	if date[1] == 0 || date[2] == 0 {
+		return time.Time{}
+	}
+
+	zone := time.FixedZone("", int(int8(date[6]))*15*60)
+	return time.Date(1900+int(date[0]), time.Month(date[1]), int(date[2]), int(date[3]), int(date[4]), int(date[5]), 0, zone)
+}
+
+func (img *Image) decodeName(name []byte) string {
+	var decoded string
+	if img.joliet {
+		codeUnits := make([]uint16, len(name)/2)
+		for i := range codeUnits {
+			codeUnits[i] = binary.BigEndian.Uint16(name[i*2:])
+		}
+		decoded = string(utf16.Decode(codeUnits))
+	} else {
+		decoded = string(name)
+	}
+
+	// drop the version suffix and the dot iso 9660 puts on names without an extension
+	if index := strings.LastIndex(decoded, ";"); index >= 0 {
+		decoded = decoded[:index]
+	}
+	return strings.TrimSuffix(decoded, ".")
+}
+
+func (img *Image) readDirectory(record directoryRecord) ([]directoryRecord, error) {
+	if record.size > maxDirectorySize {
+		return nil, fmt.Errorf("iso9660: directory at extent %d claims to be %d bytes", record.extent, record.size)
+	}
+
+	data := make([]byte, record.size)
+	n, err := img.reader.ReadAt(data, int64(record.extent)*SectorSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// a directory cut short by the end of the image only has what was read
+	data = data[:n]
+
+	records := make([]directoryRecord, 0)
+	for offset := 0; offset < len(data); {
+		recordLength := int(data[offset])
+		if recordLength == 0 {
+			// records never cross a sector, the rest of this one is padding
+			offset = (offset/SectorSize + 1) * SectorSize
+			continue
+		}
+		if offset+recordLength > len(data) {
+			return nil, fmt.Errorf("iso9660: corrupt directory record at extent %d", record.extent)
+		}
+
+		child, err := parseDirectoryRecord(data[offset : offset+recordLength])
+		if err != nil {
+			return nil, fmt.Errorf("iso9660: %w at extent %d offset %d", err, record.extent, offset)
+		}
+		offset += recordLength
+
+		// skip the . and .. entries
+		if len(child.name) == 1 && (child.name[0] == 0 || child.name[0] == 1) {
+			continue
+		}
+		records = append(records, child)
+	}
+
+	return records, nil
+}
+
+// every file and directory in the image, parents before their children
+func (img *Image) Files() ([]File, error) {
+	files := make([]File, 0)
+	visited := make(map[uint32]bool)
+
+	var walk func(directory directoryRecord, directoryPath string) error
+	walk = func(directory directoryRecord, directoryPath string) error {
+		// guard against directory loops in broken images
+		if visited[directory.extent] {
+			return nil
+		}
+		visited[directory.extent] = true
+
+		records, err := img.readDirectory(directory)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			file := File{
+				Path:    path.Join(directoryPath, img.decodeName(record.name)),
+				Size:    int64(record.size),
+				IsDir:   record.flags&flagDirectory != 0,
+				ModTime: record.recorded,
+				extent:  record.extent,
+			}
+			files = append(files, file)
+
+			if file.IsDir {
+				err = walk(record, file.Path)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return files, walk(img.root, "")
+}
+
+// looks a file up by its path, ignoring case the way the game and windows would
+func (img *Image) Find(filePath string) (File, error) {
+	files, err := img.Files()
+	if err != nil {
+		return File{}, err
+	}
+
+	filePath = strings.Trim(path.Clean("/"+strings.ReplaceAll(filePath, "\\", "/")), "/")
+	for _, file := range files {
+		if strings.EqualFold(file.Path, filePath) {
+			return file, nil
+		}
+	}
+
+	return File{}, fmt.Errorf("iso9660: `%s` not found", filePath)
+}
+
+// a reader over just the file's bytes, safe to use alongside other files from the same image
+func (img *Image) Open(file File) *io.SectionReader {
+	return io.NewSectionReader(img.reader, int64(file.extent)*SectorSize, file.Size)
+}
+
+// quick check for the CD001 signature without walking anything
+func IsIso(reader io.ReaderAt) bool {
+	signature := make([]byte, 6)
+	_, err := reader.ReadAt(signature, volumeDescriptorStart*SectorSize)
+	return err == nil && bytes.Equal(signature[1:6], []byte("CD001"))
+}
@@ -0,0 +1,309 @@
+package iso9660
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// a file or directory for the generated image, the plain name goes in the primary tree and the long one in the joliet tree
+type testEntry struct {
+	plain    string
+	joliet   string
+	data     string
+	children []*testEntry
+
+	extent uint32
+}
+
+func (e *testEntry) isDir() bool {
+	return e.children != nil
+}
+
+var testRecorded = [7]byte{99, 6, 15, 12, 30, 0, 8} // 1999-06-15 12:30 at gmt+2
+
+func testTree() *testEntry {
+	return &testEntry{children: []*testEntry{
+		{plain: "README.TXT", joliet: "Read Me First.txt", data: "hello from the disc\r\n"},
+		{plain: "DATA", joliet: "Data", children: []*testEntry{
+			{plain: "TEXTURES", joliet: "Textures", children: []*testEntry{
+				{plain: "K0001.TGA", joliet: "K0001.tga", data: strings.Repeat("\x01\x02", 1500)},
+			}},
+			{plain: "EMPTY", joliet: "Empty", children: []*testEntry{}},
+			{plain: "NOEXT", joliet: "NoExt", data: ""},
+		}},
+	}}
+}
+
+func bothEndian32(b []byte, value uint32) {
+	binary.LittleEndian.PutUint32(b, value)
+	binary.BigEndian.PutUint32(b[4:], value)
+}
+
+func testRecord(extent, size uint32, flags byte, name []byte) []byte {
+	length := 33 + len(name)
+	if length%2 != 0 {
+		length++
+	}
+
+	record := make([]byte, length)
+	record[0] = byte(length)
+	bothEndian32(record[2:], extent)
+	bothEndian32(record[10:], size)
+	copy(record[18:25], testRecorded[:])
+	record[25] = flags
+	record[32] = byte(len(name))
+	copy(record[33:], name)
+	return record
+}
+
+func encodeName(entry *testEntry, joliet bool) []byte {
+	name := entry.plain
+	if joliet {
+		name = entry.joliet
+	}
+	if !entry.isDir() {
+		if !strings.Contains(name, ".") && !joliet {
+			name += "."
+		}
+		name += ";1"
+	}
+	if !joliet {
+		return []byte(name)
+	}
+
+	var encoded []byte
+	for _, unit := range utf16.Encode([]rune(name)) {
+		encoded = binary.BigEndian.AppendUint16(encoded, unit)
+	}
+	return encoded
+}
+
+// lays out a tiny but valid image: system area, descriptors, file data and then one sector per directory and tree
+func buildTestIso(root *testEntry, joliet bool) []byte {
+	descriptorCount := 2
+	if joliet {
+		descriptorCount = 3
+	}
+	nextSector := uint32(volumeDescriptorStart + descriptorCount)
+	image := make([]byte, int(nextSector)*SectorSize)
+	allocate := func(size int) uint32 {
+		extent := nextSector
+		sectors := max((size+SectorSize-1)/SectorSize, 1)
+		image = append(image, make([]byte, sectors*SectorSize)...)
+		nextSector += uint32(sectors)
+		return extent
+	}
+
+	var placeFiles func(entry *testEntry)
+	placeFiles = func(entry *testEntry) {
+		for _, child := range entry.children {
+			if child.isDir() {
+				placeFiles(child)
+				continue
+			}
+			child.extent = allocate(len(child.data))
+			copy(image[int(child.extent)*SectorSize:], child.data)
+		}
+	}
+	placeFiles(root)
+
+	writeTree := func(joliet bool) uint32 {
+		extents := make(map[*testEntry]uint32)
+		var placeDirectories func(entry *testEntry)
+		placeDirectories = func(entry *testEntry) {
+			extents[entry] = allocate(SectorSize)
+			for _, child := range entry.children {
+				if child.isDir() {
+					placeDirectories(child)
+				}
+			}
+		}
+		placeDirectories(root)
+
+		var writeDirectory func(entry, parent *testEntry)
+		writeDirectory = func(entry, parent *testEntry) {
+			directory := testRecord(extents[entry], SectorSize, flagDirectory, []byte{0})
+			directory = append(directory, testRecord(extents[parent], SectorSize, flagDirectory, []byte{1})...)
+			for _, child := range entry.children {
+				if child.isDir() {
+					directory = append(directory, testRecord(extents[child], SectorSize, flagDirectory, encodeName(child, joliet))...)
+					writeDirectory(child, entry)
+				} else {
+					directory = append(directory, testRecord(child.extent, uint32(len(child.data)), 0, encodeName(child, joliet))...)
+				}
+			}
+			copy(image[int(extents[entry])*SectorSize:], directory)
+		}
+		writeDirectory(root, root)
+
+		return extents[root]
+	}
+
+	writeDescriptor := func(sector int, volumeType byte, rootExtent uint32) {
+		descriptor := image[sector*SectorSize : (sector+1)*SectorSize]
+		descriptor[0] = volumeType
+		copy(descriptor[1:6], "CD001")
+		descriptor[6] = 1
+		if rootExtent != 0 {
+			copy(descriptor[156:190], testRecord(rootExtent, SectorSize, flagDirectory, []byte{0}))
+		}
+	}
+
+	writeDescriptor(volumeDescriptorStart, volumeTypePrimary, writeTree(false))
+	if joliet {
+		writeDescriptor(volumeDescriptorStart+1, volumeTypeSupplemental, writeTree(true))
+		copy(image[(volumeDescriptorStart+1)*SectorSize+88:], "%/E")
+	}
+	writeDescriptor(volumeDescriptorStart+descriptorCount-1, volumeTypeTerminator, 0)
+
+	return image
+}
+
+func TestFiles(t *testing.T) {
+	recorded := time.Date(1999, time.June, 15, 12, 30, 0, 0, time.FixedZone("", 2*60*60))
+	tests := []struct {
+		name   string
+		joliet bool
+		want   []File
+	}{
+		{"plain", false, []File{
+			{Path: "README.TXT", Size: 21},
+			{Path: "DATA", Size: SectorSize, IsDir: true},
+			{Path: "DATA/TEXTURES", Size: SectorSize, IsDir: true},
+			{Path: "DATA/TEXTURES/K0001.TGA", Size: 3000},
+			{Path: "DATA/EMPTY", Size: SectorSize, IsDir: true},
+			{Path: "DATA/NOEXT", Size: 0},
+		}},
+		{"joliet", true, []File{
+			{Path: "Read Me First.txt", Size: 21},
+			{Path: "Data", Size: SectorSize, IsDir: true},
+			{Path: "Data/Textures", Size: SectorSize, IsDir: true},
+			{Path: "Data/Textures/K0001.tga", Size: 3000},
+			{Path: "Data/Empty", Size: SectorSize, IsDir: true},
+			{Path: "Data/NoExt", Size: 0},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image, err := Open(bytes.NewReader(buildTestIso(testTree(), test.joliet)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := image.Files()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(test.want) {
+				t.Fatalf("found %d files, want %d: %+v", len(files), len(test.want), files)
+			}
+			for i, file := range files {
+				want := test.want[i]
+				if file.Path != want.Path || file.Size != want.Size || file.IsDir != want.IsDir || !file.ModTime.Equal(recorded) {
+					t.Errorf("file %d = %+v, want %+v recorded %v", i, file, want, recorded)
+				}
+			}
+		})
+	}
+}
+
+func TestFindAndOpen(t *testing.T) {
+	data := buildTestIso(testTree(), true)
+	if !IsIso(bytes.NewReader(data)) {
+		t.Fatal("IsIso = false")
+	}
+
+	image, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := image.Find("\\DATA\\textures\\k0001.TGA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := io.ReadAll(image.Open(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != strings.Repeat("\x01\x02", 1500) {
+		t.Errorf("read %d bytes of the wrong data", len(contents))
+	}
+
+	_, err = image.Find("Data/Missing.txt")
+	if err == nil {
+		t.Error("found a file that is not there")
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+	rootExtent := func(data []byte) int {
+		return int(binary.LittleEndian.Uint32(data[volumeDescriptorStart*SectorSize+156+2:]))
+	}
+	// the first record after . and .. in the root directory
+	firstChild := func(data []byte) int {
+		return rootExtent(data)*SectorSize + 68
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+		openErr bool
+	}{
+		{"not an image", func(data []byte) []byte { return make([]byte, 20*SectorSize) }, true},
+		{"root name past the descriptor", func(data []byte) []byte {
+			data[volumeDescriptorStart*SectorSize+156+32] = 200
+			return data
+		}, true},
+		{"record name past the record", func(data []byte) []byte {
+			data[firstChild(data)+32] = 250
+			return data
+		}, false},
+		{"record too short", func(data []byte) []byte {
+			data[firstChild(data)] = 20
+			return data
+		}, false},
+		{"record past the directory", func(data []byte) []byte {
+			data[rootExtent(data)*SectorSize] = 255
+			binary.LittleEndian.PutUint32(data[volumeDescriptorStart*SectorSize+156+10:], 200)
+			return data
+		}, false},
+		{"huge directory", func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[volumeDescriptorStart*SectorSize+156+10:], 0xFFFFFFF0)
+			return data
+		}, false},
+		{"directory cut short by the end of the image", func(data []byte) []byte {
+			return data[:firstChild(data)+20]
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := test.corrupt(buildTestIso(testTree(), false))
+
+			image, err := Open(bytes.NewReader(data))
+			if test.openErr {
+				if err == nil {
+					t.Fatal("Open succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = image.Files()
+			if err == nil {
+				t.Fatal("Files succeeded")
+			}
+			if errors.Is(err, ErrNotIso) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
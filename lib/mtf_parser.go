@@ -5,7 +5,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 type MtfArchive struct {
@@ -18,6 +20,13 @@ type MtfVirtualFile struct {
 	FileName  string
 }
 
+// how a virtual file is stored, read separately since it means seeking to every entry
+type MtfCompressionInfo struct {
+	Compressed bool
+	StoredSize uint32 // bytes taken up in the archive, the same as TotalSize when not compressed
+	Crc        uint32 // of the decompressed data, only compressed entries carry one
+}
+
 func ScanMtfFile(mtfFile io.ReadSeeker) (MtfArchive, error) {
 	mtfFile.Seek(0, io.SeekStart)
 
@@ -37,8 +46,18 @@ func ScanMtfFile(mtfFile io.ReadSeeker) (MtfArchive, error) {
 			return archive, err
 		}
 
+		// the length counts the trailing null
+		if nameLength == 0 {
+			return archive, fmt.Errorf("entry %d has no name", len(archive.VirtualFiles))
+		}
+
 		var name = make([]byte, nameLength)
-		_, err = mtfFile.Read(name)
+		_, err = io.ReadFull(mtfFile, name)
+		if err != nil {
+			return archive, err
+		}
+
+		fileName, err := cleanVirtualFileName(string(name[:len(name)-1]))
 		if err != nil {
 			return archive, err
 		}
@@ -58,7 +77,7 @@ func ScanMtfFile(mtfFile io.ReadSeeker) (MtfArchive, error) {
 		archive.VirtualFiles = append(archive.VirtualFiles, MtfVirtualFile{
 			Offset:    offset,
 			TotalSize: totalSize,
-			FileName:  filepath.Clean(string(name[:len(name)-1])),
+			FileName:  fileName,
 		})
 	}
 
@@ -77,7 +96,7 @@ func ExtractVirtualFile(mtfFile io.ReadSeeker, virtualFile MtfVirtualFile) ([]by
 		return nil, err
 	}
 
-	if compressionTag != 0xbadbeaf && compressionTag != 0xbadbeae && compressionTag != 0xbadbeaa {
+	if !isCompressionTag(compressionTag) {
 		// just read data uncompressed
 		mtfFile.Seek(int64(virtualFile.Offset), io.SeekStart)
 
@@ -144,3 +163,56 @@ func ExtractVirtualFile(mtfFile io.ReadSeeker, virtualFile MtfVirtualFile) ([]by
 
 	return decompressedFile, nil
 }
+
+func ReadCompressionInfo(mtfFile io.ReadSeeker, virtualFile MtfVirtualFile) (MtfCompressionInfo, error) {
+	_, err := mtfFile.Seek(int64(virtualFile.Offset), io.SeekStart)
+	if err != nil {
+		return MtfCompressionInfo{}, err
+	}
+
+	var header [2]uint32
+	err = binary.Read(mtfFile, binary.LittleEndian, &header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF || (err == nil && !isCompressionTag(header[0])) {
+		// too short to hold a compression header means it cannot be compressed either
+		return MtfCompressionInfo{StoredSize: virtualFile.TotalSize}, nil
+	}
+	if err != nil {
+		return MtfCompressionInfo{}, err
+	}
+
+	// the compressed size covers the header and is followed by the crc
+	_, err = mtfFile.Seek(int64(virtualFile.Offset+header[1]), io.SeekStart)
+	if err != nil {
+		return MtfCompressionInfo{}, err
+	}
+
+	var crc uint32
+	err = binary.Read(mtfFile, binary.LittleEndian, &crc)
+	if err != nil {
+		return MtfCompressionInfo{}, err
+	}
+
+	return MtfCompressionInfo{Compressed: true, StoredSize: header[1] + 4, Crc: crc}, nil
+}
+
+func isCompressionTag(tag uint32) bool {
+	return tag == 0xbadbeaf || tag == 0xbadbeae || tag == 0xbadbeaa
+}
+
+// archives always use windows separators, normalize them so extraction builds real directories on every platform.
+// names are joined onto the output directory, so drive letters, leading separators and `..` are dropped to keep a
+// crafted archive from writing anywhere else
+func cleanVirtualFileName(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	if len(slashed) >= 2 && slashed[1] == ':' {
+		slashed = slashed[2:]
+	}
+
+	// cleaning a rooted path drops every `..` that would climb above it
+	cleaned := filepath.FromSlash(strings.TrimPrefix(path.Clean("/"+slashed), "/"))
+	if !filepath.IsLocal(cleaned) {
+		return "", fmt.Errorf("entry name `%s` is not a usable file name", name)
+	}
+
+	return cleaned, nil
+}
@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testVirtualFile struct {
+	name string
+	data []byte
+}
+
+// the layout ScanMtfFile reads: a count, then a name, offset and size per file, then the data
+func testMtf(files ...testVirtualFile) []byte {
+	var header, data bytes.Buffer
+
+	headerSize := 4
+	for _, file := range files {
+		headerSize += 4 + len(file.name) + 1 + 4 + 4
+	}
+
+	binary.Write(&header, binary.LittleEndian, uint32(len(files)))
+	for _, file := range files {
+		binary.Write(&header, binary.LittleEndian, uint32(len(file.name)+1))
+		header.WriteString(file.name)
+		header.WriteByte(0)
+		binary.Write(&header, binary.LittleEndian, uint32(headerSize+data.Len()))
+		binary.Write(&header, binary.LittleEndian, uint32(len(file.data)))
+		data.Write(file.data)
+	}
+
+	return append(header.Bytes(), data.Bytes()...)
+}
+
+func TestScanMtfFileNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{`DATA\MESHES\TORCHE.O3D`, "DATA/MESHES/TORCHE.O3D", false},
+		{`README.TXT`, "README.TXT", false},
+		{`DATA\\.\MESHES\A.O3D`, "DATA/MESHES/A.O3D", false},
+		{`..\..\evil.txt`, "evil.txt", false},
+		{`DATA\..\..\..\evil.txt`, "evil.txt", false},
+		{`../DATA/evil.txt`, "DATA/evil.txt", false},
+		{`\Windows\evil.dll`, "Windows/evil.dll", false},
+		{`C:\Windows\evil.dll`, "Windows/evil.dll", false},
+		{`C:evil.dll`, "evil.dll", false},
+		{`\\server\share\evil.dll`, "server/share/evil.dll", false},
+		{`..`, "", true},
+		{`C:\`, "", true},
+		{``, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			archive, err := ScanMtfFile(bytes.NewReader(testMtf(testVirtualFile{name: test.name, data: []byte("x")})))
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ScanMtfFile() = %+v, want an error", archive.VirtualFiles)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := archive.VirtualFiles[0].FileName; got != filepath.FromSlash(test.want) {
+				t.Errorf("FileName = %s, want %s", got, filepath.FromSlash(test.want))
+			}
+		})
+	}
+}
+
+func TestScanMtfFileEmptyNameLength(t *testing.T) {
+	var mtf bytes.Buffer
+	binary.Write(&mtf, binary.LittleEndian, uint32(1))
+	binary.Write(&mtf, binary.LittleEndian, uint32(0))
+
+	if _, err := ScanMtfFile(bytes.NewReader(mtf.Bytes())); err == nil {
+		t.Error("want an error for an entry without a name")
+	}
+}
+
+func TestExtractAllFilesStaysInOutputDirectory(t *testing.T) {
+	root := t.TempDir()
+	mtfPath := filepath.Join(root, "EVIL.MTF")
+	err := os.WriteFile(mtfPath, testMtf(
+		testVirtualFile{name: `..\..\evil.txt`, data: []byte("escaped")},
+		testVirtualFile{name: `C:\DATA\good.txt`, data: []byte("kept")},
+	), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputDirectory := filepath.Join(root, "out", "EVIL")
+	ExtractAllFiles(mtfPath, outputDirectory, ConvertOptions{})
+
+	for path, want := range map[string]string{
+		filepath.Join(outputDirectory, "evil.txt"):         "escaped",
+		filepath.Join(outputDirectory, "DATA", "good.txt"): "kept",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", path, data, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("evil.txt was written outside the output directory: %v", err)
+	}
+}
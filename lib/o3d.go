@@ -2,7 +2,9 @@ package lib
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
+	"math"
 )
 
 const (
@@ -194,3 +196,164 @@ func ExtractO3D(o3dFile io.Reader) (O3DModel, error) {
 
 	return o3dModel, nil
 }
+
+// the header counts are written as they are, so they have to agree with the slices or ExtractO3D would misread the file
+func WriteO3D(o3dFile io.Writer, o3dModel O3DModel) error {
+	if int(o3dModel.NumberOfVertices) != len(o3dModel.Vertices) || int(o3dModel.NumberOfFaces) != len(o3dModel.Faces) {
+		return fmt.Errorf("header counts %d vertices and %d faces but the model has %d and %d",
+			o3dModel.NumberOfVertices, o3dModel.NumberOfFaces, len(o3dModel.Vertices), len(o3dModel.Faces))
+	}
+	// faces index vertices with 16 bits and 0xFFFF marks an unused corner
+	if len(o3dModel.Vertices) > int(O3DUnused) {
+		return fmt.Errorf("model has %d vertices, at most %d fit", len(o3dModel.Vertices), O3DUnused)
+	}
+
+	err := binary.Write(o3dFile, binary.LittleEndian, o3dModel.NumberOfVertices)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(o3dFile, binary.LittleEndian, o3dModel.NumberOfFaces)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(o3dFile, binary.LittleEndian, o3dModel.Ignored1)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(o3dFile, binary.LittleEndian, o3dModel.Ignored2)
+	if err != nil {
+		return err
+	}
+
+	for _, vertex := range o3dModel.Vertices {
+		err = binary.Write(o3dFile, binary.LittleEndian, vertex.X)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, vertex.Y)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, vertex.Z)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, face := range o3dModel.Faces {
+		err = binary.Write(o3dFile, binary.LittleEndian, face.MaybeRed)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.MaybeGreen)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.MaybeBlue)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.MaybeAlpha)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Tx0)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Ty0)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Tx1)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Ty1)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Tx2)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Ty2)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Tx3)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Ty3)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.V0)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.V1)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.V2)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.V3)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.Ignore1)
+		if err != nil {
+			return err
+		}
+
+		err = binary.Write(o3dFile, binary.LittleEndian, face.MaterialId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the game stores uv coordinates as 255/uv, so invert to get back to a normal 0..1 range
+func DecodeO3DTexCoord(t float32) float32 {
+	if t == 0 {
+		return 0
+	}
+
+	return 255.0 / t
+}
+
+// inverse of DecodeO3DTexCoord, a 0 coordinate is stored as the largest float so the game's 255/uv lands on ~0 instead of infinity
+func EncodeO3DTexCoord(uv float32) float32 {
+	if uv == 0 {
+		return math.MaxFloat32
+	}
+
+	return 255.0 / uv
+}
@@ -0,0 +1,168 @@
+package lib
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testO3DModel() O3DModel {
+	uv := EncodeO3DTexCoord
+	return O3DModel{
+		NumberOfVertices: 5,
+		NumberOfFaces:    2,
+		Ignored1:         0x00010203,
+		Ignored2:         0xDEADBEEF,
+		Vertices: []O3DVertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1.5, Y: 0, Z: -2.25},
+			{X: 1.5, Y: 3, Z: -2.25},
+			{X: 0, Y: 3, Z: 0},
+			{X: -1, Y: 0.125, Z: 7},
+		},
+		Faces: []O3DFace{
+			{
+				// quad
+				MaybeRed: 0x10, MaybeGreen: 0x20, MaybeBlue: 0x30, MaybeAlpha: 0xFF,
+				Tx0: uv(0), Ty0: uv(0),
+				Tx1: uv(1), Ty1: uv(0),
+				Tx2: uv(1), Ty2: uv(1),
+				Tx3: uv(0), Ty3: uv(1),
+				V0: 0, V1: 1, V2: 2, V3: 3,
+				Ignore1:    0x80000001,
+				MaterialId: 1234,
+			},
+			{
+				// triangle, the fourth corner is left unused
+				MaybeRed: 0xFF, MaybeGreen: 0x00, MaybeBlue: 0x7F, MaybeAlpha: 0x80,
+				Tx0: uv(0.25), Ty0: uv(0.5),
+				Tx1: uv(0.75), Ty1: uv(0.125),
+				Tx2: uv(0.333), Ty2: uv(0.9),
+				V0: 2, V1: 3, V2: 4, V3: O3DUnused,
+				Ignore1:    7,
+				MaterialId: 65,
+			},
+		},
+	}
+}
+
+func TestWriteO3DRoundTrip(t *testing.T) {
+	model := testO3DModel()
+
+	var first bytes.Buffer
+	err := WriteO3D(&first, model)
+	if err != nil {
+		t.Fatalf("first write: %v", err)
+	}
+
+	extracted, err := ExtractO3D(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if !reflect.DeepEqual(extracted, model) {
+		t.Fatalf("extracted model differs\n got  %+v\n want %+v", extracted, model)
+	}
+
+	var second bytes.Buffer
+	err = WriteO3D(&second, extracted)
+	if err != nil {
+		t.Fatalf("second write: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatalf("rewritten bytes differ, %d vs %d bytes", first.Len(), second.Len())
+	}
+
+	// header, then 12 bytes a vertex and 50 a face
+	wantSize := 16 + 12*len(model.Vertices) + 50*len(model.Faces)
+	if first.Len() != wantSize {
+		t.Errorf("wrote %d bytes, want %d", first.Len(), wantSize)
+	}
+}
+
+func TestO3DFaceFieldsSurvive(t *testing.T) {
+	model := testO3DModel()
+
+	var buffer bytes.Buffer
+	err := WriteO3D(&buffer, model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extracted, err := ExtractO3D(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quad, triangle := extracted.Faces[0], extracted.Faces[1]
+	if quad.V3 == O3DUnused {
+		t.Errorf("quad lost its fourth vertex")
+	}
+	if triangle.V3 != O3DUnused {
+		t.Errorf("triangle V3 = %#x, want O3DUnused", triangle.V3)
+	}
+	if quad.MaterialId != 1234 || triangle.MaterialId != 65 {
+		t.Errorf("material ids = %d, %d", quad.MaterialId, triangle.MaterialId)
+	}
+	if quad.Ignore1 != 0x80000001 || triangle.Ignore1 != 7 {
+		t.Errorf("face flags = %#x, %#x", quad.Ignore1, triangle.Ignore1)
+	}
+	if triangle.MaybeRed != 0xFF || triangle.MaybeBlue != 0x7F || triangle.MaybeAlpha != 0x80 {
+		t.Errorf("face color = %d %d %d %d", triangle.MaybeRed, triangle.MaybeGreen, triangle.MaybeBlue, triangle.MaybeAlpha)
+	}
+	if extracted.Ignored1 != 0x00010203 || extracted.Ignored2 != 0xDEADBEEF {
+		t.Errorf("header flags = %#x, %#x", extracted.Ignored1, extracted.Ignored2)
+	}
+}
+
+func TestO3DTexCoordQuantisation(t *testing.T) {
+	tests := []float32{0, 1, 0.5, 0.25, 0.333, 0.9, 1.0 / 255}
+	for _, uv := range tests {
+		stored := EncodeO3DTexCoord(uv)
+		decoded := DecodeO3DTexCoord(stored)
+		if math.Abs(float64(decoded-uv)) > 1e-5 {
+			t.Errorf("uv %v stored as %v decodes to %v", uv, stored, decoded)
+		}
+		// the stored float goes through the file unchanged
+		if EncodeO3DTexCoord(decoded) != stored && uv != 0 {
+			t.Errorf("uv %v does not settle, %v vs %v", uv, EncodeO3DTexCoord(decoded), stored)
+		}
+	}
+
+	if stored := EncodeO3DTexCoord(0); stored != math.MaxFloat32 {
+		t.Errorf("zero uv stored as %v, want MaxFloat32", stored)
+	}
+	if decoded := DecodeO3DTexCoord(0); decoded != 0 {
+		t.Errorf("zero stored uv decodes to %v", decoded)
+	}
+}
+
+func TestWriteO3DRejectsBadCounts(t *testing.T) {
+	tooManyVertices := testO3DModel()
+	tooManyVertices.Vertices = make([]O3DVertex, 0x10000)
+	tooManyVertices.NumberOfVertices = 0x10000
+
+	tests := []struct {
+		name   string
+		change func(o3dModel *O3DModel)
+	}{
+		{"more vertices in the header", func(o3dModel *O3DModel) { o3dModel.NumberOfVertices++ }},
+		{"fewer faces in the header", func(o3dModel *O3DModel) { o3dModel.NumberOfFaces-- }},
+		{"vertex added without the header", func(o3dModel *O3DModel) { o3dModel.Vertices = append(o3dModel.Vertices, O3DVertex{}) }},
+		{"too many vertices", func(o3dModel *O3DModel) { *o3dModel = tooManyVertices }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o3dModel := testO3DModel()
+			test.change(&o3dModel)
+
+			var buffer bytes.Buffer
+			err := WriteO3D(&buffer, o3dModel)
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			if buffer.Len() != 0 {
+				t.Errorf("wrote %d bytes before failing", buffer.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+type O3DSeverity string
+
+const (
+	O3DSeverityError   O3DSeverity = "error"
+	O3DSeverityWarning O3DSeverity = "warning"
+	O3DSeverityInfo    O3DSeverity = "info"
+)
+
+const (
+	O3DIssueTrailingData    = "trailing-data" // reported by readers, ExtractO3D stops where the header counts say the model ends
+	O3DIssueIndexOutOfRange = "index-out-of-range"
+	O3DIssueDegenerateFace  = "degenerate-face"
+	O3DIssueDuplicateFace   = "duplicate-face"
+	O3DIssueInvalidVertex   = "invalid-vertex"
+	O3DIssueUnusedVertex    = "unused-vertex"
+	O3DIssueNonPlanarQuad   = "non-planar-quad"
+	O3DIssueMissingTexture  = "missing-texture"
+)
+
+const (
+	o3dPlanarityTolerance    = 0.01
+	o3dDegenerateAreaEpsilon = 1e-9
+)
+
+type O3DIssue struct {
+	Severity   O3DSeverity `json:"severity"`
+	Kind       string      `json:"kind"`
+	Face       int         `json:"face"`
+	Vertex     int         `json:"vertex"`
+	MaterialId int         `json:"material_id"`
+	Message    string      `json:"message"`
+}
+
+// hasTexture is asked once per distinct material id, pass nil to skip the texture check entirely
+func ValidateO3D(o3dModel O3DModel, hasTexture func(materialId uint16) bool) []O3DIssue {
+	issues := make([]O3DIssue, 0)
+	addIssue := func(severity O3DSeverity, kind string, face, vertex, materialId int, format string, args ...any) {
+		issues = append(issues, O3DIssue{
+			Severity:   severity,
+			Kind:       kind,
+			Face:       face,
+			Vertex:     vertex,
+			MaterialId: materialId,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, vertex := range o3dModel.Vertices {
+		for _, value := range []float32{vertex.X, vertex.Y, vertex.Z} {
+			if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+				addIssue(O3DSeverityError, O3DIssueInvalidVertex, -1, i, -1, "vertex %d has a non-finite coordinate (%f, %f, %f)", i, vertex.X, vertex.Y, vertex.Z)
+				break
+			}
+		}
+	}
+
+	usedVertices := make([]bool, len(o3dModel.Vertices))
+	seenFaces := make(map[[4]uint16]int)
+	for i, face := range o3dModel.Faces {
+		indexes := o3dFaceIndexes(face)
+
+		inRange := true
+		for _, index := range indexes {
+			if int(index) >= len(o3dModel.Vertices) {
+				addIssue(O3DSeverityError, O3DIssueIndexOutOfRange, i, int(index), int(face.MaterialId), "face %d references vertex %d but the model only has %d vertices", i, index, len(o3dModel.Vertices))
+				inRange = false
+				continue
+			}
+			usedVertices[index] = true
+		}
+
+		// the same corners in any order or winding count as the same face
+		key := [4]uint16{O3DUnused, O3DUnused, O3DUnused, O3DUnused}
+		sorted := slices.Clone(indexes)
+		slices.Sort(sorted)
+		copy(key[:], sorted)
+		if firstFace, ok := seenFaces[key]; ok {
+			addIssue(O3DSeverityWarning, O3DIssueDuplicateFace, i, -1, int(face.MaterialId), "face %d duplicates face %d", i, firstFace)
+		} else {
+			seenFaces[key] = i
+		}
+
+		if len(slices.Compact(sorted)) != len(indexes) {
+			addIssue(O3DSeverityWarning, O3DIssueDegenerateFace, i, -1, int(face.MaterialId), "face %d repeats a vertex", i)
+			continue
+		}
+
+		if !inRange {
+			continue
+		}
+
+		corners := make([][3]float64, len(indexes))
+		for j, index := range indexes {
+			vertex := o3dModel.Vertices[index]
+			corners[j] = [3]float64{float64(vertex.X), float64(vertex.Y), float64(vertex.Z)}
+		}
+
+		normal := vec3Cross(vec3Subtract(corners[1], corners[0]), vec3Subtract(corners[2], corners[0]))
+		normalLength := vec3Length(normal)
+		if normalLength < o3dDegenerateAreaEpsilon {
+			addIssue(O3DSeverityWarning, O3DIssueDegenerateFace, i, -1, int(face.MaterialId), "face %d has no area", i)
+			continue
+		}
+
+		if len(corners) == 4 {
+			// distance of the fourth corner from the plane of the first three, relative to the size of the face
+			distance := math.Abs(vec3Dot(vec3Subtract(corners[3], corners[0]), normal)) / normalLength
+			size := max(vec3Length(vec3Subtract(corners[2], corners[0])), vec3Length(vec3Subtract(corners[3], corners[1])))
+			if size > 0 && distance/size > o3dPlanarityTolerance {
+				addIssue(O3DSeverityWarning, O3DIssueNonPlanarQuad, i, -1, int(face.MaterialId), "face %d is a non-planar quad (off by %f)", i, distance)
+			}
+		}
+	}
+
+	for i, used := range usedVertices {
+		if !used {
+			addIssue(O3DSeverityInfo, O3DIssueUnusedVertex, -1, i, -1, "vertex %d is not used by any face", i)
+		}
+	}
+
+	if hasTexture != nil {
+		for _, materialId := range O3DMaterialIds(o3dModel) {
+			if !hasTexture(materialId) {
+				addIssue(O3DSeverityWarning, O3DIssueMissingTexture, -1, -1, int(materialId), "material %d has no matching texture", materialId)
+			}
+		}
+	}
+
+	return issues
+}
+
+func HasO3DErrors(issues []O3DIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == O3DSeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func o3dFaceIndexes(face O3DFace) []uint16 {
+	if face.V3 == O3DUnused {
+		return []uint16{face.V0, face.V1, face.V2}
+	}
+
+	return []uint16{face.V0, face.V1, face.V2, face.V3}
+}
+
+func vec3Subtract(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func vec3Cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func vec3Dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func vec3Length(a [3]float64) float64 {
+	return math.Sqrt(vec3Dot(a, a))
+}
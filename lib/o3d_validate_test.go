@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// a flat unit square and a triangle rising out of one of its edges, nothing to report
+func testValidO3DModel() O3DModel {
+	return O3DModel{
+		NumberOfVertices: 5,
+		NumberOfFaces:    2,
+		Vertices: []O3DVertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+			{X: 0.5, Y: 0, Z: 1},
+		},
+		Faces: []O3DFace{
+			{V0: 0, V1: 1, V2: 2, V3: 3, MaterialId: 12},
+			{V0: 0, V1: 1, V2: 4, V3: O3DUnused, MaterialId: 40},
+		},
+	}
+}
+
+func TestValidateO3D(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(model *O3DModel)
+		kinds      []string
+		wantErrors bool
+	}{
+		{"valid", func(model *O3DModel) {}, nil, false},
+		{"index out of range", func(model *O3DModel) {
+			model.Faces[0].V2 = 9
+		}, []string{O3DIssueIndexOutOfRange, O3DIssueUnusedVertex}, true},
+		{"repeated vertex", func(model *O3DModel) {
+			model.Faces[1].V1 = 4
+		}, []string{O3DIssueDegenerateFace}, false},
+		{"no area", func(model *O3DModel) {
+			model.Vertices[4] = O3DVertex{X: 0.5, Y: 0, Z: 0}
+		}, []string{O3DIssueDegenerateFace}, false},
+		{"duplicate face with the other winding", func(model *O3DModel) {
+			model.Faces = append(model.Faces, O3DFace{V0: 1, V1: 0, V2: 4, V3: O3DUnused, MaterialId: 40})
+		}, []string{O3DIssueDuplicateFace}, false},
+		{"nan vertex", func(model *O3DModel) {
+			model.Vertices[4].X = float32(math.NaN())
+		}, []string{O3DIssueInvalidVertex}, true},
+		{"infinite vertex", func(model *O3DModel) {
+			model.Vertices[4].Z = float32(math.Inf(-1))
+		}, []string{O3DIssueInvalidVertex}, true},
+		{"unused vertex", func(model *O3DModel) {
+			model.Vertices = append(model.Vertices, O3DVertex{X: 2, Y: 2, Z: 2})
+		}, []string{O3DIssueUnusedVertex}, false},
+		{"non-planar quad", func(model *O3DModel) {
+			model.Vertices[3].Z = 0.5
+		}, []string{O3DIssueNonPlanarQuad}, false},
+		{"slightly off quad", func(model *O3DModel) {
+			model.Vertices[3].Z = 0.001
+		}, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model := testValidO3DModel()
+			test.modify(&model)
+
+			issues := ValidateO3D(model, nil)
+			kinds := make([]string, 0)
+			for _, issue := range issues {
+				kinds = append(kinds, issue.Kind)
+			}
+			if !slices.Equal(kinds, test.kinds) {
+				t.Errorf("issues = %+v, want kinds %v", issues, test.kinds)
+			}
+			if HasO3DErrors(issues) != test.wantErrors {
+				t.Errorf("HasO3DErrors = %v, want %v", !test.wantErrors, test.wantErrors)
+			}
+		})
+	}
+}
+
+func TestValidateO3DMissingTexture(t *testing.T) {
+	asked := make([]uint16, 0)
+	issues := ValidateO3D(testValidO3DModel(), func(materialId uint16) bool {
+		asked = append(asked, materialId)
+		return materialId == 12
+	})
+
+	if want := []uint16{12, 40}; !reflect.DeepEqual(asked, want) {
+		t.Errorf("asked for materials %v, want %v", asked, want)
+	}
+	if len(issues) != 1 || issues[0].Kind != O3DIssueMissingTexture || issues[0].MaterialId != 40 {
+		t.Errorf("issues = %+v, want material 40 missing its texture", issues)
+	}
+}
@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+)
+
+func ObjMaterialName(materialId uint16) string {
+	return fmt.Sprintf("K%04d", materialId)
+}
+
+// ordered list of every distinct material used by the model's faces
+func O3DMaterialIds(o3dModel O3DModel) []uint16 {
+	materialIds := make([]uint16, 0)
+	for _, face := range o3dModel.Faces {
+		if !slices.Contains(materialIds, face.MaterialId) {
+			materialIds = append(materialIds, face.MaterialId)
+		}
+	}
+	slices.Sort(materialIds)
+
+	return materialIds
+}
+
+func WriteObj(objFile io.Writer, o3dModel O3DModel, mtlFileName string) error {
+	writer := bufio.NewWriter(objFile)
+
+	fmt.Fprintf(writer, "# exported by stone-tools\n")
+	if mtlFileName != "" {
+		fmt.Fprintf(writer, "mtllib %s\n", mtlFileName)
+	}
+
+	for _, vertex := range o3dModel.Vertices {
+		fmt.Fprintf(writer, "v %f %f %f\n", vertex.X, vertex.Y, vertex.Z)
+	}
+
+	// every face corner gets its own texture coordinate since the uvs live on the faces, not the vertices
+	textureIndex := 1
+	for _, materialId := range O3DMaterialIds(o3dModel) {
+		fmt.Fprintf(writer, "usemtl %s\n", ObjMaterialName(materialId))
+
+		for _, face := range o3dModel.Faces {
+			if face.MaterialId != materialId {
+				continue
+			}
+
+			indexes := []uint16{face.V0, face.V1, face.V2}
+			texCoords := []float32{face.Tx0, face.Ty0, face.Tx1, face.Ty1, face.Tx2, face.Ty2}
+			if face.V3 != O3DUnused {
+				indexes = append(indexes, face.V3)
+				texCoords = append(texCoords, face.Tx3, face.Ty3)
+			}
+
+			for i := range indexes {
+				// obj has v pointing up, the game has it pointing down
+				fmt.Fprintf(writer, "vt %f %f\n", DecodeO3DTexCoord(texCoords[i*2]), 1.0-DecodeO3DTexCoord(texCoords[i*2+1]))
+			}
+
+			fmt.Fprintf(writer, "f")
+			for i, index := range indexes {
+				fmt.Fprintf(writer, " %d/%d", int(index)+1, textureIndex+i)
+			}
+			fmt.Fprintf(writer, "\n")
+
+			textureIndex += len(indexes)
+		}
+	}
+
+	return writer.Flush()
+}
+
+// textureFileNames maps a material id to the image the material should use, materials without an entry get no map_Kd
+func WriteMtl(mtlFile io.Writer, o3dModel O3DModel, textureFileNames map[uint16]string) error {
+	writer := bufio.NewWriter(mtlFile)
+
+	fmt.Fprintf(writer, "# exported by stone-tools\n")
+	for _, materialId := range O3DMaterialIds(o3dModel) {
+		fmt.Fprintf(writer, "\nnewmtl %s\n", ObjMaterialName(materialId))
+		fmt.Fprintf(writer, "Ka 0.000000 0.000000 0.000000\n")
+		fmt.Fprintf(writer, "Kd 1.000000 1.000000 1.000000\n")
+		fmt.Fprintf(writer, "Ks 0.000000 0.000000 0.000000\n")
+		fmt.Fprintf(writer, "d 1.000000\n")
+		fmt.Fprintf(writer, "illum 1\n")
+
+		if textureFileName, ok := textureFileNames[materialId]; ok {
+			fmt.Fprintf(writer, "map_Kd %s\n", textureFileName)
+		}
+	}
+
+	return writer.Flush()
+}
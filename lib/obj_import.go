@@ -0,0 +1,232 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type objCorner struct {
+	vertexIndex  int
+	textureIndex int
+}
+
+type objTexCoord struct {
+	U float32
+	V float32
+}
+
+// materialIds lets a caller map custom usemtl names, anything not in it must be named K#### or be a plain number
+func ReadObj(objFile io.Reader, materialIds map[string]uint16) (O3DModel, error) {
+	var o3dModel O3DModel
+	texCoords := make([]objTexCoord, 0)
+	currentMaterialId := uint16(0)
+
+	scanner := bufio.NewScanner(objFile)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "v":
+			values, err := parseObjFloats(fields[1:], 3)
+			if err != nil {
+				return o3dModel, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+
+			o3dModel.Vertices = append(o3dModel.Vertices, O3DVertex{X: values[0], Y: values[1], Z: values[2]})
+			if len(o3dModel.Vertices) > int(O3DUnused) {
+				return o3dModel, fmt.Errorf("line %d: mesh has more than %d vertices", lineNumber, O3DUnused)
+			}
+		case "vt":
+			values, err := parseObjFloats(fields[1:], 2)
+			if err != nil {
+				return o3dModel, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+
+			texCoords = append(texCoords, objTexCoord{U: values[0], V: values[1]})
+		case "usemtl":
+			if len(fields) < 2 {
+				return o3dModel, fmt.Errorf("line %d: usemtl without a material name", lineNumber)
+			}
+
+			materialId, err := parseObjMaterialName(fields[1], materialIds)
+			if err != nil {
+				return o3dModel, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			currentMaterialId = materialId
+		case "f":
+			corners := make([]objCorner, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+				corner, err := parseObjCorner(field, len(o3dModel.Vertices), len(texCoords))
+				if err != nil {
+					return o3dModel, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				corners = append(corners, corner)
+			}
+
+			if len(corners) < 3 {
+				return o3dModel, fmt.Errorf("line %d: face needs at least 3 vertices", lineNumber)
+			}
+
+			if len(corners) <= 4 {
+				o3dModel.Faces = append(o3dModel.Faces, newO3DFace(corners, texCoords, currentMaterialId))
+				continue
+			}
+
+			// anything bigger than a quad gets fanned out into triangles
+			for i := 1; i+1 < len(corners); i++ {
+				triangle := []objCorner{corners[0], corners[i], corners[i+1]}
+				o3dModel.Faces = append(o3dModel.Faces, newO3DFace(triangle, texCoords, currentMaterialId))
+			}
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return o3dModel, err
+	}
+
+	o3dModel.NumberOfVertices = uint32(len(o3dModel.Vertices))
+	o3dModel.NumberOfFaces = uint32(len(o3dModel.Faces))
+	return o3dModel, nil
+}
+
+func newO3DFace(corners []objCorner, texCoords []objTexCoord, materialId uint16) O3DFace {
+	face := O3DFace{
+		MaybeRed:   255,
+		MaybeGreen: 255,
+		MaybeBlue:  255,
+		MaybeAlpha: 255,
+		V3:         O3DUnused,
+		MaterialId: materialId,
+	}
+
+	vertexIndexes := []*uint16{&face.V0, &face.V1, &face.V2, &face.V3}
+	textureFields := []*float32{&face.Tx0, &face.Ty0, &face.Tx1, &face.Ty1, &face.Tx2, &face.Ty2, &face.Tx3, &face.Ty3}
+	for i, corner := range corners {
+		*vertexIndexes[i] = uint16(corner.vertexIndex)
+
+		var texCoord objTexCoord
+		if corner.textureIndex >= 0 {
+			texCoord = texCoords[corner.textureIndex]
+		}
+
+		// flip v back to the game's top-down orientation, the reverse of WriteObj
+		*textureFields[i*2] = EncodeO3DTexCoord(texCoord.U)
+		*textureFields[i*2+1] = EncodeO3DTexCoord(1.0 - texCoord.V)
+	}
+
+	return face
+}
+
+func parseObjFloats(fields []string, count int) ([]float32, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d values but found %d", count, len(fields))
+	}
+
+	values := make([]float32, count)
+	for i := range count {
+		value, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = float32(value)
+	}
+
+	return values, nil
+}
+
+// parses a face corner like `1`, `1/2`, `1//3` or `1/2/3`, returned indexes are zero based and -1 when missing
+func parseObjCorner(field string, vertexCount, texCoordCount int) (objCorner, error) {
+	parts := strings.Split(field, "/")
+
+	vertexIndex, err := resolveObjIndex(parts[0], vertexCount)
+	if err != nil {
+		return objCorner{}, err
+	}
+
+	textureIndex := -1
+	if len(parts) > 1 && parts[1] != "" {
+		textureIndex, err = resolveObjIndex(parts[1], texCoordCount)
+		if err != nil {
+			return objCorner{}, err
+		}
+	}
+
+	return objCorner{vertexIndex: vertexIndex, textureIndex: textureIndex}, nil
+}
+
+func resolveObjIndex(value string, count int) (int, error) {
+	index, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	// negative indexes count back from the latest element
+	if index < 0 {
+		index = count + index
+	} else {
+		index--
+	}
+
+	if index < 0 || index >= count {
+		return 0, fmt.Errorf("index %s is out of range", value)
+	}
+
+	return index, nil
+}
+
+// every usemtl name in the file in the order they first appear, so callers can map the ones ReadObj would not understand
+func ObjMaterialNames(objFile io.Reader) ([]string, error) {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(objFile)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "usemtl" && !seen[fields[1]] {
+			seen[fields[1]] = true
+			names = append(names, fields[1])
+		}
+	}
+
+	return names, scanner.Err()
+}
+
+// the id a usemtl name stands for on its own, false when it needs an entry in ReadObj's materialIds
+func ObjMaterialId(name string) (uint16, bool) {
+	materialId, err := parseObjMaterialName(name, nil)
+	return materialId, err == nil
+}
+
+func parseObjMaterialName(name string, materialIds map[string]uint16) (uint16, error) {
+	if materialId, ok := materialIds[name]; ok {
+		return materialId, nil
+	}
+
+	digits := name
+	if len(digits) > 1 && (digits[0] == 'K' || digits[0] == 'k') {
+		digits = digits[1:]
+	}
+
+	// allow suffixes like K0012_DIFFUSE, only the leading digits matter
+	end := 0
+	for end < len(digits) && digits[end] >= '0' && digits[end] <= '9' {
+		end++
+	}
+
+	materialId, err := strconv.ParseUint(digits[:end], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("can not map material `%s` to a material id", name)
+	}
+
+	return uint16(materialId), nil
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testObj = `v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+usemtl wood
+f 1/1 2/1 3/1
+usemtl K0012_DIFFUSE
+f 1/1 2/1 3/1
+usemtl wood
+f 3/1 2/1 1/1
+`
+
+func TestObjMaterialNames(t *testing.T) {
+	names, err := ObjMaterialNames(strings.NewReader(testObj))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"wood", "K0012_DIFFUSE"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestObjMaterialId(t *testing.T) {
+	tests := []struct {
+		name string
+		id   uint16
+		ok   bool
+	}{
+		{"K0012", 12, true},
+		{"k0012_DIFFUSE", 12, true},
+		{"40", 40, true},
+		{"wood", 0, false},
+		{"K", 0, false},
+		{"K99999", 0, false},
+	}
+	for _, test := range tests {
+		id, ok := ObjMaterialId(test.name)
+		if id != test.id || ok != test.ok {
+			t.Errorf("ObjMaterialId(%q) = %d, %v, want %d, %v", test.name, id, ok, test.id, test.ok)
+		}
+	}
+}
+
+func TestReadObjMaterialIds(t *testing.T) {
+	if _, err := ReadObj(strings.NewReader(testObj), nil); err == nil {
+		t.Error("want an error for `wood` without a mapping")
+	}
+
+	model, err := ReadObj(strings.NewReader(testObj), map[string]uint16{"wood": 9000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []uint16{model.Faces[0].MaterialId, model.Faces[1].MaterialId, model.Faces[2].MaterialId}
+	if want := []uint16{9000, 12, 9000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("material ids = %v, want %v", got, want)
+	}
+}
+
+func TestReadObjVertexLimit(t *testing.T) {
+	vertices := func(count int) string {
+		return strings.Repeat("v 0 0 0\n", count)
+	}
+
+	model, err := ReadObj(strings.NewReader(vertices(int(O3DUnused))), nil)
+	if err != nil {
+		t.Fatalf("%d vertices should still fit: %v", O3DUnused, err)
+	}
+	if model.NumberOfVertices != uint32(O3DUnused) {
+		t.Errorf("NumberOfVertices = %d, want %d", model.NumberOfVertices, O3DUnused)
+	}
+
+	// one more would make its index the unused corner marker
+	if _, err := ReadObj(strings.NewReader(vertices(int(O3DUnused)+1)), nil); err == nil {
+		t.Errorf("want an error for %d vertices", int(O3DUnused)+1)
+	}
+}
+
+func TestReadObjFaces(t *testing.T) {
+	tests := []struct {
+		name  string
+		face  string
+		faces [][4]uint16
+	}{
+		{"triangle", "f 1 2 3", [][4]uint16{{0, 1, 2, O3DUnused}}},
+		{"quad", "f 1 2 3 4", [][4]uint16{{0, 1, 2, 3}}},
+		{"pentagon", "f 1 2 3 4 5", [][4]uint16{{0, 1, 2, O3DUnused}, {0, 2, 3, O3DUnused}, {0, 3, 4, O3DUnused}}},
+		{"hexagon from the end", "f -6 -5 -4 -3 -2 -1", [][4]uint16{{0, 1, 2, O3DUnused}, {0, 2, 3, O3DUnused}, {0, 3, 4, O3DUnused}, {0, 4, 5, O3DUnused}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := strings.Repeat("v 0 0 0\n", 6) + "usemtl K0003\n" + test.face + "\n"
+			model, err := ReadObj(strings.NewReader(obj), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			faces := make([][4]uint16, 0)
+			for _, face := range model.Faces {
+				faces = append(faces, [4]uint16{face.V0, face.V1, face.V2, face.V3})
+				if face.MaterialId != 3 {
+					t.Errorf("face material %d, want 3", face.MaterialId)
+				}
+			}
+			if !reflect.DeepEqual(faces, test.faces) {
+				t.Errorf("faces = %v, want %v", faces, test.faces)
+			}
+			if model.NumberOfFaces != uint32(len(test.faces)) {
+				t.Errorf("NumberOfFaces = %d, want %d", model.NumberOfFaces, len(test.faces))
+			}
+		})
+	}
+}
+
+func TestReadObjTexCoords(t *testing.T) {
+	obj := `v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0.5 0.25
+vt 0 1
+vt 1 0
+f 1/1 2/2 3/3
+f 1 2 3
+`
+	model, err := ReadObj(strings.NewReader(obj), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// v is flipped back to top-down and a 0 is stored as the largest float instead of dividing by zero
+	textured := model.Faces[0]
+	got := []float32{textured.Tx0, textured.Ty0, textured.Tx1, textured.Ty1, textured.Tx2, textured.Ty2}
+	want := []float32{255 / 0.5, 255 / 0.75, math.MaxFloat32, math.MaxFloat32, 255, 255}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("texture coordinates = %v, want %v", got, want)
+	}
+
+	// corners without a vt sit at u 0, v 0 in obj terms
+	untextured := model.Faces[1]
+	got = []float32{untextured.Tx0, untextured.Ty0}
+	want = []float32{math.MaxFloat32, 255}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("texture coordinates without a vt = %v, want %v", got, want)
+	}
+}
+
+func TestWriteObjReadObjRoundTrip(t *testing.T) {
+	model := testObjModel()
+
+	var obj bytes.Buffer
+	err := WriteObj(&obj, model, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ReadObj(&obj, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(imported.Vertices, model.Vertices) {
+		t.Errorf("vertices = %v, want %v", imported.Vertices, model.Vertices)
+	}
+
+	// the export groups faces by material and the import gives every face an opaque white color
+	want := []O3DFace{model.Faces[1], model.Faces[0], model.Faces[2]}
+	for i := range want {
+		want[i].MaybeRed, want[i].MaybeGreen, want[i].MaybeBlue, want[i].MaybeAlpha = 255, 255, 255, 255
+	}
+	if !reflect.DeepEqual(imported.Faces, want) {
+		t.Errorf("faces =\n%+v\nwant\n%+v", imported.Faces, want)
+	}
+}
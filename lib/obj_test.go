@@ -0,0 +1,139 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// a quad and a triangle sharing material 1234 with a triangle of material 65 between them
+func testObjModel() O3DModel {
+	uv := EncodeO3DTexCoord
+	model := testO3DModel()
+	model.Faces = []O3DFace{
+		{
+			Tx0: uv(0), Ty0: uv(0),
+			Tx1: uv(1), Ty1: uv(0),
+			Tx2: uv(1), Ty2: uv(1),
+			Tx3: uv(0), Ty3: uv(1),
+			V0: 0, V1: 1, V2: 2, V3: 3,
+			MaterialId: 1234,
+		},
+		{
+			Tx0: uv(0.5), Ty0: uv(0.25),
+			Tx1: uv(0.25), Ty1: uv(0.5),
+			Tx2: uv(1), Ty2: uv(0.5),
+			V0: 2, V1: 3, V2: 4, V3: O3DUnused,
+			MaterialId: 65,
+		},
+		{
+			Tx0: uv(0), Ty0: uv(0),
+			Tx1: uv(1), Ty1: uv(0),
+			Tx2: uv(1), Ty2: uv(1),
+			V0: 0, V1: 1, V2: 4, V3: O3DUnused,
+			MaterialId: 1234,
+		},
+	}
+	model.NumberOfFaces = uint32(len(model.Faces))
+	return model
+}
+
+func TestWriteObj(t *testing.T) {
+	var obj bytes.Buffer
+	err := WriteObj(&obj, testObjModel(), "model.mtl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// faces are grouped by material in id order, quads keep their four corners and v is flipped
+	expected := `# exported by stone-tools
+mtllib model.mtl
+v 0.000000 0.000000 0.000000
+v 1.500000 0.000000 -2.250000
+v 1.500000 3.000000 -2.250000
+v 0.000000 3.000000 0.000000
+v -1.000000 0.125000 7.000000
+usemtl K0065
+vt 0.500000 0.750000
+vt 0.250000 0.500000
+vt 1.000000 0.500000
+f 3/1 4/2 5/3
+usemtl K1234
+vt 0.000000 1.000000
+vt 1.000000 1.000000
+vt 1.000000 0.000000
+vt 0.000000 0.000000
+f 1/4 2/5 3/6 4/7
+vt 0.000000 1.000000
+vt 1.000000 1.000000
+vt 1.000000 0.000000
+f 1/8 2/9 5/10
+`
+	if difference := firstObjDifference(expected, obj.String()); difference != "" {
+		t.Error(difference)
+	}
+}
+
+func TestWriteObjWithoutMtl(t *testing.T) {
+	var obj bytes.Buffer
+	err := WriteObj(&obj, testObjModel(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(obj.Bytes(), []byte("mtllib")) {
+		t.Errorf("no mtl file name but the obj has a mtllib line:\n%s", obj.String())
+	}
+}
+
+func TestWriteMtl(t *testing.T) {
+	var mtl bytes.Buffer
+	err := WriteMtl(&mtl, testObjModel(), map[uint16]string{1234: "textures/K1234.TGA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the material with a texture gets a map_Kd
+	expected := `# exported by stone-tools
+
+newmtl K0065
+Ka 0.000000 0.000000 0.000000
+Kd 1.000000 1.000000 1.000000
+Ks 0.000000 0.000000 0.000000
+d 1.000000
+illum 1
+
+newmtl K1234
+Ka 0.000000 0.000000 0.000000
+Kd 1.000000 1.000000 1.000000
+Ks 0.000000 0.000000 0.000000
+d 1.000000
+illum 1
+map_Kd textures/K1234.TGA
+`
+	if difference := firstObjDifference(expected, mtl.String()); difference != "" {
+		t.Error(difference)
+	}
+}
+
+// the first line that differs, empty when both match
+func firstObjDifference(expected string, actual string) string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var expectedLine, actualLine string
+		if i < len(expectedLines) {
+			expectedLine = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			actualLine = actualLines[i]
+		}
+		if expectedLine != actualLine {
+			return fmt.Sprintf("line %d = %q, want %q", i+1, actualLine, expectedLine)
+		}
+	}
+
+	return ""
+}
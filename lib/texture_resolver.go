@@ -0,0 +1,158 @@
+package lib
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// textures are named K####<anything>.TGA, with R#### being the lower resolution copy of the same texture.
+// all the leading digits are taken so K12345 is not mistaken for material 1234
+var texturePattern = regexp.MustCompile(`(?i)^([KR])(\d+).*\.tga$`)
+
+type TextureCandidate struct {
+	Path       string
+	MaterialId uint16
+	Bank       string // name of the directory holding the texture, e.g. DRAGONBLADE
+	LowRes     bool
+	searchRank int
+}
+
+type TextureResolver struct {
+	// searched in order, put mod directories before the extracted game data so they win
+	SearchPaths []string
+	// banks listed here are preferred over every other bank, in the order given, read on every lookup
+	BankPriority []string
+	// prefer the R#### textures over the full size K#### ones, read on every lookup
+	PreferLowRes bool
+
+	once       sync.Once
+	candidates map[uint16][]TextureCandidate
+}
+
+func NewTextureResolver(searchPaths ...string) *TextureResolver {
+	return &TextureResolver{
+		SearchPaths: searchPaths,
+	}
+}
+
+// walks every search path once, the texture banks are big so doing it per lookup is not an option.
+// only the walk is cached, the candidates are ranked when looked up so priority changes apply
+func (r *TextureResolver) index() {
+	r.once.Do(func() {
+		r.candidates = make(map[uint16][]TextureCandidate)
+		for searchRank, searchPath := range r.SearchPaths {
+			filepath.WalkDir(searchPath, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					// unreadable directories are skipped rather than failing the whole search
+					if d != nil && d.IsDir() && path != searchPath {
+						return fs.SkipDir
+					}
+					return nil
+				}
+				if d.IsDir() {
+					return nil
+				}
+
+				match := texturePattern.FindStringSubmatch(d.Name())
+				if match == nil {
+					return nil
+				}
+
+				// ids past the uint16 range can not belong to any material
+				materialId, err := strconv.ParseUint(match[2], 10, 16)
+				if err != nil {
+					return nil
+				}
+
+				r.candidates[uint16(materialId)] = append(r.candidates[uint16(materialId)], TextureCandidate{
+					Path:       path,
+					MaterialId: uint16(materialId),
+					Bank:       filepath.Base(filepath.Dir(path)),
+					LowRes:     strings.EqualFold(match[1], "R"),
+					searchRank: searchRank,
+				})
+				return nil
+			})
+		}
+	})
+}
+
+func (r *TextureResolver) bankRank(bank string) int {
+	for i, preferredBank := range r.BankPriority {
+		if strings.EqualFold(preferredBank, bank) {
+			return i
+		}
+	}
+
+	return len(r.BankPriority)
+}
+
+func (r *TextureResolver) compare(a, b TextureCandidate) int {
+	if a.searchRank != b.searchRank {
+		return a.searchRank - b.searchRank
+	}
+
+	if bankRankA, bankRankB := r.bankRank(a.Bank), r.bankRank(b.Bank); bankRankA != bankRankB {
+		return bankRankA - bankRankB
+	}
+
+	if a.LowRes != b.LowRes {
+		if a.LowRes == r.PreferLowRes {
+			return -1
+		}
+		return 1
+	}
+
+	return strings.Compare(a.Path, b.Path)
+}
+
+// every material id with at least one texture in the search paths, in order
+func (r *TextureResolver) MaterialIds() []uint16 {
+	r.index()
+	materialIds := make([]uint16, 0, len(r.candidates))
+	for materialId := range r.candidates {
+		materialIds = append(materialIds, materialId)
+	}
+	slices.Sort(materialIds)
+
+	return materialIds
+}
+
+// every texture that could be used for the material, best match first
+func (r *TextureResolver) Candidates(materialId uint16) []TextureCandidate {
+	r.index()
+	candidates := slices.Clone(r.candidates[materialId])
+	slices.SortStableFunc(candidates, r.compare)
+	return candidates
+}
+
+func (r *TextureResolver) Resolve(materialId uint16) (string, bool) {
+	r.index()
+	candidates := r.candidates[materialId]
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	return slices.MinFunc(candidates, r.compare).Path, true
+}
+
+// resolves every material the model uses, returning the material ids nothing was found for
+func (r *TextureResolver) ResolveModel(o3dModel O3DModel) (map[uint16]string, []uint16) {
+	resolved := make(map[uint16]string)
+	unresolved := make([]uint16, 0)
+	for _, materialId := range O3DMaterialIds(o3dModel) {
+		texturePath, ok := r.Resolve(materialId)
+		if !ok {
+			unresolved = append(unresolved, materialId)
+			continue
+		}
+		resolved[materialId] = texturePath
+	}
+
+	return resolved, unresolved
+}
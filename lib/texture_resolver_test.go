@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTextures(t *testing.T, root string, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(fullPath, nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTextureResolverPriorityAfterLookup(t *testing.T) {
+	root := t.TempDir()
+	writeTextures(t, root,
+		"ARMOR/K0012.TGA",
+		"ARMOR/R0012.TGA",
+		"WEAPONS/K0012_SWORD.TGA",
+		"WEAPONS/K0040.TGA",
+	)
+
+	resolver := NewTextureResolver(root)
+	resolve := func() string {
+		t.Helper()
+		path, ok := resolver.Resolve(12)
+		if !ok {
+			t.Fatal("material 12 did not resolve")
+		}
+		rel, _ := filepath.Rel(root, path)
+		return filepath.ToSlash(rel)
+	}
+
+	// no priority, full size textures and then path order decide
+	if got := resolve(); got != "ARMOR/K0012.TGA" {
+		t.Errorf("default resolve = %s", got)
+	}
+
+	// changing the options after the first lookup still applies
+	resolver.BankPriority = []string{"weapons"}
+	if got := resolve(); got != "WEAPONS/K0012_SWORD.TGA" {
+		t.Errorf("with weapons first resolve = %s", got)
+	}
+
+	resolver.BankPriority = nil
+	resolver.PreferLowRes = true
+	if got := resolve(); got != "ARMOR/R0012.TGA" {
+		t.Errorf("preferring low res resolve = %s", got)
+	}
+
+	candidates := resolver.Candidates(12)
+	if len(candidates) != 3 || !candidates[0].LowRes {
+		t.Errorf("candidates = %+v, want the low res one first of 3", candidates)
+	}
+
+	if _, ok := resolver.Resolve(41); ok {
+		t.Error("material 41 should not resolve")
+	}
+}
+
+func TestTextureResolverSearchPathOrder(t *testing.T) {
+	mod, game := t.TempDir(), t.TempDir()
+	writeTextures(t, game, "ARMOR/K0012.TGA")
+	writeTextures(t, mod, "MYMOD/K0012.TGA")
+
+	resolver := NewTextureResolver(mod, game)
+	resolver.BankPriority = []string{"armor"}
+	path, ok := resolver.Resolve(12)
+	if !ok || filepath.Dir(path) != filepath.Join(mod, "MYMOD") {
+		t.Errorf("resolved %s, the earlier search path should win over bank priority", path)
+	}
+}
+
+func TestTextureResolverMaterialIds(t *testing.T) {
+	root := t.TempDir()
+	writeTextures(t, root,
+		"ARMOR/K12345.TGA",
+		"ARMOR/K99999_BIG.TGA",
+		"ARMOR/K65535.tga",
+		"ARMOR/K0007_SHIELD.TGA",
+	)
+
+	resolver := NewTextureResolver(root)
+	tests := []struct {
+		materialId uint16
+		want       string
+	}{
+		{1234, ""},
+		{12345, "K12345.TGA"},
+		{9999, ""},
+		{65535, "K65535.tga"},
+		{7, "K0007_SHIELD.TGA"},
+	}
+	for _, test := range tests {
+		path, ok := resolver.Resolve(test.materialId)
+		if ok != (test.want != "") || (ok && filepath.Base(path) != test.want) {
+			t.Errorf("Resolve(%d) = %s, %v, want %q", test.materialId, path, ok, test.want)
+		}
+	}
+}
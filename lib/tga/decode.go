@@ -0,0 +1,214 @@
+package tga
+
+import (
+	"bufio"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+)
+
+func readHeader(r io.Reader) (Header, error) {
+	var header Header
+	err := binary.Read(r, binary.LittleEndian, &header)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return header, err
+	}
+
+	return header, header.validate()
+}
+
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	header, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      int(header.Width),
+		Height:     int(header.Height),
+	}, nil
+}
+
+// Decode reads every variant found in the game data (color mapped, true color and grayscale, raw or rle, any origin) into an *image.NRGBA
+func Decode(r io.Reader) (image.Image, error) {
+	reader := bufio.NewReader(r)
+
+	header, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// image id is free form text we have no use for
+	_, err = reader.Discard(int(header.IdLength))
+	if err != nil {
+		return nil, err
+	}
+
+	var palette []color.NRGBA
+	if header.ColorMapType == 1 {
+		entrySize := (int(header.ColorMapEntrySize) + 7) / 8
+		colorMap := make([]byte, int(header.ColorMapLength)*entrySize)
+		_, err = io.ReadFull(reader, colorMap)
+		if err != nil {
+			return nil, err
+		}
+
+		palette = make([]color.NRGBA, header.ColorMapLength)
+		for i := range palette {
+			palette[i] = decodeTrueColor(colorMap[i*entrySize:(i+1)*entrySize], header.ColorMapEntrySize, header.alphaBits() > 0 || header.ColorMapEntrySize == 32)
+		}
+	}
+
+	width, height := int(header.Width), int(header.Height)
+	bytesPerPixel := (int(header.PixelDepth) + 7) / 8
+	// the buffer grows with the data actually read, a corrupt header claiming 65535x65535
+	// runs out of input long before it could ask for gigabytes
+	var pixelData []byte
+	if header.isRle() {
+		pixelData, err = readRle(reader, width*height*bytesPerPixel, bytesPerPixel)
+	} else {
+		pixelData, err = readRows(reader, width*bytesPerPixel, height)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a zero alpha depth means the alpha channel, if any, is garbage, except 32-bit images commonly forget to set it
+	hasAlpha := header.alphaBits() > 0 || header.PixelDepth == 32
+	rightToLeft := header.Descriptor&descriptorRightToLeft != 0
+	topToBottom := header.Descriptor&descriptorTopToBottom != 0
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	anyVisible := false
+	for i := 0; i < width*height; i++ {
+		pixel := pixelData[i*bytesPerPixel : (i+1)*bytesPerPixel]
+
+		var c color.NRGBA
+		switch header.baseType() {
+		case imageTypeColorMapped:
+			index := int(pixel[0])
+			if bytesPerPixel == 2 {
+				index = int(binary.LittleEndian.Uint16(pixel))
+			}
+			index -= int(header.ColorMapFirst)
+			if index >= 0 && index < len(palette) {
+				c = palette[index]
+			}
+		case imageTypeTrueColor:
+			c = decodeTrueColor(pixel, header.PixelDepth, hasAlpha)
+		case imageTypeGrayscale:
+			c = color.NRGBA{R: pixel[0], G: pixel[0], B: pixel[0], A: 255}
+			if bytesPerPixel == 2 {
+				c.A = pixel[1]
+			}
+		}
+		if c.A != 0 {
+			anyVisible = true
+		}
+
+		x, y := i%width, i/width
+		if rightToLeft {
+			x = width - 1 - x
+		}
+		if !topToBottom {
+			y = height - 1 - y
+		}
+		img.SetNRGBA(x, y, c)
+	}
+
+	// 32-bit images that never set alpha would otherwise come out fully transparent
+	if !anyVisible && header.alphaBits() == 0 {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 255
+		}
+	}
+
+	return img, nil
+}
+
+// pixels are stored little-endian as BGR(A), or as 1-5-5-5 ARGB for the 15/16-bit variants
+func decodeTrueColor(pixel []byte, depth uint8, hasAlpha bool) color.NRGBA {
+	switch depth {
+	case 15, 16:
+		value := binary.LittleEndian.Uint16(pixel)
+		c := color.NRGBA{
+			R: expand5(uint8(value>>10) & 0x1F),
+			G: expand5(uint8(value>>5) & 0x1F),
+			B: expand5(uint8(value) & 0x1F),
+			A: 255,
+		}
+		if depth == 16 && hasAlpha && value&0x8000 == 0 {
+			c.A = 0
+		}
+		return c
+	case 24:
+		return color.NRGBA{R: pixel[2], G: pixel[1], B: pixel[0], A: 255}
+	default:
+		c := color.NRGBA{R: pixel[2], G: pixel[1], B: pixel[0], A: pixel[3]}
+		if !hasAlpha {
+			c.A = 255
+		}
+		return c
+	}
+}
+
+func expand5(value uint8) uint8 {
+	return value<<3 | value>>2
+}
+
+func readRows(reader io.Reader, rowSize int, rowCount int) ([]byte, error) {
+	pixelData := make([]byte, 0, rowSize)
+	for range rowCount {
+		offset := len(pixelData)
+		pixelData = slices.Grow(pixelData, rowSize)[:offset+rowSize]
+		_, err := io.ReadFull(reader, pixelData[offset:])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pixelData, nil
+}
+
+// each packet starts with a header byte, the high bit says whether the next pixel repeats or raw pixels follow
+func readRle(reader *bufio.Reader, size int, bytesPerPixel int) ([]byte, error) {
+	pixelData := make([]byte, 0, min(size, 64*1024))
+	pixel := make([]byte, bytesPerPixel)
+	for len(pixelData) < size {
+		packetHeader, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		count := int(packetHeader&0x7F) + 1
+		if len(pixelData)+count*bytesPerPixel > size {
+			// runs are not supposed to cross the end of the image, clamp rather than fail
+			count = (size - len(pixelData)) / bytesPerPixel
+		}
+
+		if packetHeader&0x80 != 0 {
+			_, err = io.ReadFull(reader, pixel)
+			if err != nil {
+				return nil, err
+			}
+			for range count {
+				pixelData = append(pixelData, pixel...)
+			}
+		} else {
+			offset := len(pixelData)
+			pixelData = slices.Grow(pixelData, count*bytesPerPixel)[:offset+count*bytesPerPixel]
+			_, err = io.ReadFull(reader, pixelData[offset:])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pixelData, nil
+}
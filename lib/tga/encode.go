@@ -0,0 +1,135 @@
+package tga
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+type Options struct {
+	BitsPerPixel int  // 16, 24 or 32, defaults to 32
+	Compressed   bool // write rle packets instead of raw pixels
+	TopLeft      bool // store rows top to bottom instead of the classic bottom-up order
+}
+
+// Encode writes m as a true color tga, a nil Options writes uncompressed 32-bit bottom-up rows which everything can read
+func Encode(w io.Writer, m image.Image, o *Options) error {
+	options := Options{BitsPerPixel: 32}
+	if o != nil {
+		options = *o
+		if options.BitsPerPixel == 0 {
+			options.BitsPerPixel = 32
+		}
+	}
+
+	var alphaBits uint8
+	switch options.BitsPerPixel {
+	case 16:
+		alphaBits = 1
+	case 24:
+		alphaBits = 0
+	case 32:
+		alphaBits = 8
+	default:
+		return UnsupportedError(fmt.Sprintf("encoding %d-bit pixels", options.BitsPerPixel))
+	}
+
+	bounds := m.Bounds()
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 || bounds.Dx() > 0xFFFF || bounds.Dy() > 0xFFFF {
+		return FormatError(fmt.Sprintf("can not encode a %dx%d image", bounds.Dx(), bounds.Dy()))
+	}
+
+	header := Header{
+		ImageType:  imageTypeTrueColor,
+		Width:      uint16(bounds.Dx()),
+		Height:     uint16(bounds.Dy()),
+		PixelDepth: uint8(options.BitsPerPixel),
+		Descriptor: alphaBits,
+	}
+	if options.Compressed {
+		header.ImageType = imageTypeRleTrueColor
+	}
+	if options.TopLeft {
+		header.Descriptor |= descriptorTopToBottom
+	}
+
+	writer := bufio.NewWriter(w)
+	err := binary.Write(writer, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	bytesPerPixel := options.BitsPerPixel / 8
+	row := make([]byte, bounds.Dx()*bytesPerPixel)
+	for i := range bounds.Dy() {
+		y := bounds.Max.Y - 1 - i
+		if options.TopLeft {
+			y = bounds.Min.Y + i
+		}
+
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			encodeTrueColor(row[(x-bounds.Min.X)*bytesPerPixel:], c, options.BitsPerPixel)
+		}
+
+		if options.Compressed {
+			writeRle(writer, row, bytesPerPixel)
+		} else {
+			writer.Write(row)
+		}
+	}
+
+	return writer.Flush()
+}
+
+func encodeTrueColor(pixel []byte, c color.NRGBA, depth int) {
+	switch depth {
+	case 16:
+		value := uint16(c.R>>3)<<10 | uint16(c.G>>3)<<5 | uint16(c.B>>3)
+		if c.A >= 128 {
+			value |= 0x8000
+		}
+		binary.LittleEndian.PutUint16(pixel, value)
+	case 24:
+		pixel[0], pixel[1], pixel[2] = c.B, c.G, c.R
+	default:
+		pixel[0], pixel[1], pixel[2], pixel[3] = c.B, c.G, c.R, c.A
+	}
+}
+
+// packs a single row, packets never cross rows which keeps older readers happy
+func writeRle(writer *bufio.Writer, row []byte, bytesPerPixel int) {
+	pixelCount := len(row) / bytesPerPixel
+	pixelAt := func(i int) []byte {
+		return row[i*bytesPerPixel : (i+1)*bytesPerPixel]
+	}
+
+	for i := 0; i < pixelCount; {
+		// count how many times the current pixel repeats
+		run := 1
+		for i+run < pixelCount && run < 128 && bytes.Equal(pixelAt(i), pixelAt(i+run)) {
+			run++
+		}
+
+		if run > 1 {
+			writer.WriteByte(0x80 | byte(run-1))
+			writer.Write(pixelAt(i))
+			i += run
+			continue
+		}
+
+		// otherwise gather raw pixels until the next repeat starts
+		raw := 1
+		for i+raw < pixelCount && raw < 128 && (i+raw+1 >= pixelCount || !bytes.Equal(pixelAt(i+raw), pixelAt(i+raw+1))) {
+			raw++
+		}
+
+		writer.WriteByte(byte(raw - 1))
+		writer.Write(row[i*bytesPerPixel : (i+raw)*bytesPerPixel])
+		i += raw
+	}
+}
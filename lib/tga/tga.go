@@ -0,0 +1,97 @@
+package tga
+
+import (
+	"fmt"
+)
+
+const (
+	imageTypeNone           uint8 = 0
+	imageTypeColorMapped    uint8 = 1
+	imageTypeTrueColor      uint8 = 2
+	imageTypeGrayscale      uint8 = 3
+	imageTypeRleColorMapped uint8 = 9
+	imageTypeRleTrueColor   uint8 = 10
+	imageTypeRleGrayscale   uint8 = 11
+
+	descriptorAlphaBits   uint8 = 0x0F
+	descriptorRightToLeft uint8 = 0x10
+	descriptorTopToBottom uint8 = 0x20
+
+	headerSize = 18
+)
+
+type Header struct {
+	IdLength          uint8
+	ColorMapType      uint8
+	ImageType         uint8
+	ColorMapFirst     uint16
+	ColorMapLength    uint16
+	ColorMapEntrySize uint8
+	XOrigin           uint16
+	YOrigin           uint16
+	Width             uint16
+	Height            uint16
+	PixelDepth        uint8
+	Descriptor        uint8
+}
+
+func (h Header) isRle() bool {
+	return h.ImageType == imageTypeRleColorMapped || h.ImageType == imageTypeRleTrueColor || h.ImageType == imageTypeRleGrayscale
+}
+
+func (h Header) baseType() uint8 {
+	if h.isRle() {
+		return h.ImageType - 8
+	}
+
+	return h.ImageType
+}
+
+func (h Header) alphaBits() uint8 {
+	return h.Descriptor & descriptorAlphaBits
+}
+
+type FormatError string
+
+func (e FormatError) Error() string { return "tga: invalid format: " + string(e) }
+
+type UnsupportedError string
+
+func (e UnsupportedError) Error() string { return "tga: unsupported feature: " + string(e) }
+
+func (h Header) validate() error {
+	if h.Width == 0 || h.Height == 0 {
+		return FormatError(fmt.Sprintf("image has no size (%dx%d)", h.Width, h.Height))
+	}
+
+	switch h.baseType() {
+	case imageTypeColorMapped:
+		if h.ColorMapType != 1 {
+			return FormatError("color mapped image without a color map")
+		}
+		if h.PixelDepth != 8 && h.PixelDepth != 16 {
+			return UnsupportedError(fmt.Sprintf("%d-bit color map indexes", h.PixelDepth))
+		}
+		switch h.ColorMapEntrySize {
+		case 15, 16, 24, 32:
+		default:
+			return UnsupportedError(fmt.Sprintf("%d-bit color map entries", h.ColorMapEntrySize))
+		}
+	case imageTypeTrueColor:
+		switch h.PixelDepth {
+		case 15, 16, 24, 32:
+		default:
+			return UnsupportedError(fmt.Sprintf("%d-bit true color pixels", h.PixelDepth))
+		}
+	case imageTypeGrayscale:
+		if h.PixelDepth != 8 && h.PixelDepth != 16 {
+			return UnsupportedError(fmt.Sprintf("%d-bit grayscale pixels", h.PixelDepth))
+		}
+	case imageTypeNone:
+		return UnsupportedError("image without image data")
+	default:
+		return UnsupportedError(fmt.Sprintf("image type %d", h.ImageType))
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package tga
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// a small image with runs for the rle packets, raw stretches and a few alpha levels
+func testImage(opaque bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 7, 5))
+	for y := range 5 {
+		for x := range 7 {
+			c := color.NRGBA{R: uint8(x * 40), G: uint8(y * 60), B: uint8((x + y) * 20), A: 255}
+			if x < 3 {
+				// a run across the start of every row
+				c = color.NRGBA{R: 200, G: 10, B: uint8(y), A: 255}
+			}
+			if !opaque && x == 6 {
+				c.A = uint8(y * 50)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func quantize16(c color.NRGBA) color.NRGBA {
+	q := color.NRGBA{R: expand5(c.R >> 3), G: expand5(c.G >> 3), B: expand5(c.B >> 3), A: 0}
+	if c.A >= 128 {
+		q.A = 255
+	}
+	return q
+}
+
+func compareImages(t *testing.T, got image.Image, want *image.NRGBA, convert func(color.NRGBA) color.NRGBA) {
+	t.Helper()
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+	for y := want.Rect.Min.Y; y < want.Rect.Max.Y; y++ {
+		for x := want.Rect.Min.X; x < want.Rect.Max.X; x++ {
+			wantColor := want.NRGBAAt(x, y)
+			if convert != nil {
+				wantColor = convert(wantColor)
+			}
+			gotColor := color.NRGBAModel.Convert(got.At(x, y)).(color.NRGBA)
+			if gotColor != wantColor {
+				t.Fatalf("pixel %d,%d = %v, want %v", x, y, gotColor, wantColor)
+			}
+		}
+	}
+}
+
+func TestEncodeDecodeTrueColor(t *testing.T) {
+	for _, depth := range []int{16, 24, 32} {
+		for _, compressed := range []bool{false, true} {
+			for _, topLeft := range []bool{false, true} {
+				name := fmt.Sprintf("%d-bit compressed=%v topLeft=%v", depth, compressed, topLeft)
+				t.Run(name, func(t *testing.T) {
+					want := testImage(depth == 24)
+
+					var buffer bytes.Buffer
+					err := Encode(&buffer, want, &Options{BitsPerPixel: depth, Compressed: compressed, TopLeft: topLeft})
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					got, err := Decode(&buffer)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					var convert func(color.NRGBA) color.NRGBA
+					if depth == 16 {
+						convert = quantize16
+					}
+					compareImages(t, got, want, convert)
+				})
+			}
+		}
+	}
+}
+
+// writes a tga by hand for the types Encode does not produce, pixels are given top to bottom, left to right
+func buildTga(header Header, colorMap []byte, pixels []byte) []byte {
+	width, height := int(header.Width), int(header.Height)
+	bytesPerPixel := (int(header.PixelDepth) + 7) / 8
+	rowSize := width * bytesPerPixel
+
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, header)
+	buffer.Write(colorMap)
+
+	writer := bufio.NewWriter(&buffer)
+	row := make([]byte, rowSize)
+	for i := range height {
+		y := height - 1 - i
+		if header.Descriptor&descriptorTopToBottom != 0 {
+			y = i
+		}
+		for x := range width {
+			stored := x
+			if header.Descriptor&descriptorRightToLeft != 0 {
+				stored = width - 1 - x
+			}
+			copy(row[stored*bytesPerPixel:], pixels[(y*width+x)*bytesPerPixel:(y*width+x+1)*bytesPerPixel])
+		}
+
+		if header.isRle() {
+			writeRle(writer, row, bytesPerPixel)
+		} else {
+			writer.Write(row)
+		}
+	}
+	writer.Flush()
+
+	return buffer.Bytes()
+}
+
+func TestDecodeColorMapped(t *testing.T) {
+	palette := []color.NRGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}, {R: 10, G: 20, B: 30, A: 255}}
+	colorMap := make([]byte, 0, len(palette)*3)
+	for _, c := range palette {
+		colorMap = append(colorMap, c.B, c.G, c.R)
+	}
+
+	const first = 16
+	width, height := 6, 3
+	want := image.NewNRGBA(image.Rect(0, 0, width, height))
+	indexes := make([]byte, width*height)
+	for i := range indexes {
+		index := (i / 4) % len(palette)
+		indexes[i] = byte(first + index)
+		want.SetNRGBA(i%width, i/width, palette[index])
+	}
+
+	for _, imageType := range []uint8{imageTypeColorMapped, imageTypeRleColorMapped} {
+		for _, descriptor := range []uint8{0, descriptorTopToBottom, descriptorRightToLeft} {
+			t.Run(fmt.Sprintf("type %d descriptor %#x", imageType, descriptor), func(t *testing.T) {
+				header := Header{
+					ColorMapType:      1,
+					ImageType:         imageType,
+					ColorMapFirst:     first,
+					ColorMapLength:    uint16(len(palette)),
+					ColorMapEntrySize: 24,
+					Width:             uint16(width),
+					Height:            uint16(height),
+					PixelDepth:        8,
+					Descriptor:        descriptor,
+				}
+
+				got, err := Decode(bytes.NewReader(buildTga(header, colorMap, indexes)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				compareImages(t, got, want, nil)
+			})
+		}
+	}
+}
+
+func TestDecodeGrayscale(t *testing.T) {
+	width, height := 5, 4
+	for _, depth := range []uint8{8, 16} {
+		for _, imageType := range []uint8{imageTypeGrayscale, imageTypeRleGrayscale} {
+			for _, descriptor := range []uint8{0, descriptorTopToBottom} {
+				t.Run(fmt.Sprintf("%d-bit type %d descriptor %#x", depth, imageType, descriptor), func(t *testing.T) {
+					bytesPerPixel := int(depth) / 8
+					want := image.NewNRGBA(image.Rect(0, 0, width, height))
+					pixels := make([]byte, width*height*bytesPerPixel)
+					for i := range width * height {
+						value := uint8(i / 3 * 17)
+						c := color.NRGBA{R: value, G: value, B: value, A: 255}
+						pixels[i*bytesPerPixel] = value
+						if depth == 16 {
+							c.A = uint8(255 - i)
+							pixels[i*bytesPerPixel+1] = c.A
+						}
+						want.SetNRGBA(i%width, i/width, c)
+					}
+
+					header := Header{
+						ImageType:  imageType,
+						Width:      uint16(width),
+						Height:     uint16(height),
+						PixelDepth: depth,
+						Descriptor: descriptor,
+					}
+					if depth == 16 {
+						header.Descriptor |= 8
+					}
+
+					got, err := Decode(bytes.NewReader(buildTga(header, nil, pixels)))
+					if err != nil {
+						t.Fatal(err)
+					}
+					compareImages(t, got, want, nil)
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	var buffer bytes.Buffer
+	err := Encode(&buffer, testImage(false), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := DecodeConfig(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 7 || config.Height != 5 {
+		t.Errorf("config is %dx%d, want 7x5", config.Width, config.Height)
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	huge := Header{ImageType: imageTypeTrueColor, Width: 0xFFFF, Height: 0xFFFF, PixelDepth: 32}
+	hugeRle := huge
+	hugeRle.ImageType = imageTypeRleTrueColor
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", []byte{0, 0, 2, 0}},
+		{"huge raw image with a few pixels", append(headerBytes(huge), make([]byte, 64)...)},
+		{"huge rle image with a few packets", append(headerBytes(hugeRle), 0xFF, 1, 2, 3, 4, 0xFF, 5, 6, 7, 8)},
+		{"no size", headerBytes(Header{ImageType: imageTypeTrueColor, PixelDepth: 32})},
+		{"unsupported depth", headerBytes(Header{ImageType: imageTypeTrueColor, Width: 1, Height: 1, PixelDepth: 12})},
+		{"color mapped without a map", headerBytes(Header{ImageType: imageTypeColorMapped, Width: 1, Height: 1, PixelDepth: 8})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Decode(bytes.NewReader(test.data))
+			if err == nil {
+				t.Fatal("want an error")
+			}
+
+			var formatError FormatError
+			var unsupportedError UnsupportedError
+			if !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) && !errors.As(err, &formatError) && !errors.As(err, &unsupportedError) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func headerBytes(header Header) []byte {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, header)
+	return buffer.Bytes()
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"stone-tools/view"
 )
 
 func main() {
-	err := view.Run()
+	profile := flag.String("profile", "", "settings profile to use and remember, e.g. one for a retail install and one for a modded one")
+	flag.Parse()
+
+	err := view.Run(*profile)
 	if err != nil {
 		fmt.Printf("An Error Occurred: %v", err)
 		os.Exit(1)
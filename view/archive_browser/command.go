@@ -0,0 +1,68 @@
+package archive_browser
+
+import (
+	"stone-tools/lib"
+	"stone-tools/view/preview"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// both carry the archive they are for, another browser may be open further down the screen stack
+type archiveLoadedMsg struct {
+	archivePath string
+	entries     []entry
+	err         error
+}
+
+// reads the table of contents plus how each entry is stored, which means touching every entry once
+func loadArchive(archivePath string) tea.Cmd {
+	return func() tea.Msg {
+		mtfFile, closer, err := lib.OpenArchiveFile(archivePath)
+		if err != nil {
+			return archiveLoadedMsg{archivePath: archivePath, err: err}
+		}
+		defer closer.Close()
+
+		archive, err := lib.ScanMtfFile(mtfFile)
+		if err != nil {
+			return archiveLoadedMsg{archivePath: archivePath, err: err}
+		}
+
+		entries := make([]entry, 0, len(archive.VirtualFiles))
+		for _, virtualFile := range archive.VirtualFiles {
+			compressionInfo, err := lib.ReadCompressionInfo(mtfFile, virtualFile)
+			if err != nil {
+				return archiveLoadedMsg{archivePath: archivePath, err: err}
+			}
+
+			entries = append(entries, entry{virtualFile: virtualFile, compressionInfo: compressionInfo})
+		}
+
+		return archiveLoadedMsg{archivePath: archivePath, entries: entries}
+	}
+}
+
+type previewLoadedMsg struct {
+	archivePath string
+	fileName    string
+	content     preview.Content
+	err         error
+}
+
+// decompresses a single entry into memory, nothing is written out
+func loadPreview(archivePath string, virtualFile lib.MtfVirtualFile) tea.Cmd {
+	return func() tea.Msg {
+		mtfFile, closer, err := lib.OpenArchiveFile(archivePath)
+		if err != nil {
+			return previewLoadedMsg{archivePath: archivePath, fileName: virtualFile.FileName, err: err}
+		}
+		defer closer.Close()
+
+		data, err := lib.ExtractVirtualFile(mtfFile, virtualFile)
+		if err != nil {
+			return previewLoadedMsg{archivePath: archivePath, fileName: virtualFile.FileName, err: err}
+		}
+
+		return previewLoadedMsg{archivePath: archivePath, fileName: virtualFile.FileName, content: preview.New(virtualFile.FileName, data)}
+	}
+}
@@ -0,0 +1,332 @@
+package archive_browser
+
+import (
+	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/archive_extractor"
+	"stone-tools/view/filters"
+	"stone-tools/view/preview"
+	"stone-tools/view/router"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type model struct {
+	conf           config.Config
+	archivePath    string
+	convertOptions lib.ConvertOptions
+
+	loading bool
+	err     error
+	entries []entry
+	root    *node
+
+	rows     []row
+	cursor   int
+	offset   int
+	selected map[string]bool // virtual file names
+	reveal   string          // put the cursor on this file once the archive is loaded
+
+	filter    textinput.Model
+	filtering bool
+
+	showPreview bool
+	preview     previewState
+}
+
+type previewState struct {
+	fileName string // the file being shown, or loaded when loading is set
+	loading  bool
+	content  preview.Content
+	err      error
+}
+
+func New(conf config.Config, archivePath string, convertOptions lib.ConvertOptions) model {
+	filter := textinput.New()
+	filter.Prompt = "Filter: "
+
+	return model{
+		conf:           conf,
+		archivePath:    archivePath,
+		convertOptions: convertOptions,
+
+		loading:  true,
+		root:     &node{},
+		selected: make(map[string]bool),
+		filter:   filter,
+
+		showPreview: true,
+	}
+}
+
+// opens the tree up to the given virtual file and puts the cursor on it, for jumping in from a search
+func (m model) Reveal(fileName string) model {
+	m.reveal = fileName
+	return m
+}
+
+func (m model) CapturingInput() bool {
+	return m.filtering
+}
+
+// a preview that finishes while the extractor is open still shows once it closes
+func (m model) ReceivesInBackground(msg tea.Msg) bool {
+	switch msg.(type) {
+	case archiveLoadedMsg, previewLoadedMsg:
+		return true
+	}
+	return false
+}
+
+func (m model) Init() tea.Cmd {
+	return loadArchive(m.archivePath)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case archiveLoadedMsg:
+		if msg.archivePath != m.archivePath || !m.loading {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		m.entries = msg.entries
+		m.root = buildTree(m.entries)
+		m.refreshRows()
+		if m.reveal != "" {
+			m.revealFile(m.reveal)
+		}
+		return m, m.updatePreview()
+	case previewLoadedMsg:
+		// the cursor may have moved on while this was decompressing
+		if msg.archivePath == m.archivePath && msg.fileName == m.preview.fileName {
+			m.preview = previewState{fileName: msg.fileName, content: msg.content, err: msg.err}
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+		return m.updateKeys(msg)
+	case tea.WindowSizeMsg:
+		m.moveCursor(0)
+	}
+
+	return m, nil
+}
+
+func (m model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filtering = false
+		m.filter.Blur()
+		m.filter.SetValue("")
+		m.refreshRows()
+		return m, nil
+	case "enter", "tab", "up", "down":
+		// keep the filter applied and go back to moving around the results
+		m.filtering = false
+		m.filter.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	m.cursor = 0
+	m.refreshRows()
+	return m, tea.Batch(cmd, m.updatePreview())
+}
+
+func (m model) updateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if m.filter.Value() != "" {
+			m.filter.SetValue("")
+			m.refreshRows()
+			return m, nil
+		}
+		return m, router.Back()
+	case "/":
+		if m.loading || m.err != nil {
+			return m, nil
+		}
+		m.filtering = true
+		return m, m.filter.Focus()
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-m.pageHeight())
+	case "pgdown":
+		m.moveCursor(m.pageHeight())
+	case "home", "g":
+		m.moveCursor(-len(m.rows))
+	case "end", "G":
+		m.moveCursor(len(m.rows))
+	case "right", "l":
+		if current := m.current(); current != nil && current.isDir() {
+			current.expanded = true
+			m.refreshRows()
+		}
+	case "left", "h":
+		m.collapse()
+	case " ":
+		if current := m.current(); current != nil {
+			m.toggleSelection(current.files())
+		}
+	case "a":
+		files := make([]*node, 0, len(m.rows))
+		for _, r := range m.rows {
+			files = append(files, r.node.files()...)
+		}
+		m.toggleSelection(files)
+	case "p":
+		m.showPreview = !m.showPreview
+	case "enter":
+		return m.enter()
+	}
+
+	return m, m.updatePreview()
+}
+
+// starts loading the file under the cursor if the pane is not already showing it
+func (m *model) updatePreview() tea.Cmd {
+	current := m.current()
+	if !m.showPreview || current == nil || current.isDir() || current.entry.virtualFile.FileName == m.preview.fileName {
+		return nil
+	}
+
+	m.preview = previewState{fileName: current.entry.virtualFile.FileName, loading: true}
+	return loadPreview(m.archivePath, current.entry.virtualFile)
+}
+
+func (m model) enter() (tea.Model, tea.Cmd) {
+	fileNames := m.selectedFileNames()
+	if len(fileNames) == 0 {
+		current := m.current()
+		if current == nil {
+			return m, nil
+		}
+		if current.isDir() {
+			current.expanded = !current.expanded
+			m.refreshRows()
+			return m, m.updatePreview()
+		}
+
+		// nothing picked, so just the file under the cursor
+		fileNames = []string{current.entry.virtualFile.FileName}
+	}
+
+	return m, router.Push(archive_extractor.New(m.conf, m.archivePath, fileNames, m.convertOptions))
+}
+
+func (m *model) revealFile(fileName string) {
+	for _, file := range m.root.files() {
+		if file.entry.virtualFile.FileName != fileName {
+			continue
+		}
+
+		for parent := file.parent; parent != nil; parent = parent.parent {
+			parent.expanded = true
+		}
+		m.refreshRows()
+
+		for i, r := range m.rows {
+			if r.node == file {
+				m.moveCursor(i - m.cursor)
+			}
+		}
+		return
+	}
+}
+
+// collapses the directory under the cursor, or jumps up to the parent when there is nothing to collapse
+func (m *model) collapse() {
+	current := m.current()
+	if current == nil {
+		return
+	}
+
+	if current.isDir() && current.expanded {
+		current.expanded = false
+		m.refreshRows()
+		return
+	}
+
+	if m.filter.Value() != "" || current.parent == nil || current.parent == m.root {
+		return
+	}
+	for i, r := range m.rows {
+		if r.node == current.parent {
+			m.moveCursor(i - m.cursor)
+			return
+		}
+	}
+}
+
+// selects every file given unless they already all are, in which case they are all deselected
+func (m *model) toggleSelection(files []*node) {
+	allSelected := true
+	for _, file := range files {
+		allSelected = allSelected && m.selected[file.entry.virtualFile.FileName]
+	}
+
+	for _, file := range files {
+		if allSelected {
+			delete(m.selected, file.entry.virtualFile.FileName)
+		} else {
+			m.selected[file.entry.virtualFile.FileName] = true
+		}
+	}
+}
+
+// in archive order, which keeps the extraction reading forwards through the file
+func (m model) selectedFileNames() []string {
+	fileNames := make([]string, 0, len(m.selected))
+	for _, e := range m.entries {
+		if m.selected[e.virtualFile.FileName] {
+			fileNames = append(fileNames, e.virtualFile.FileName)
+		}
+	}
+
+	return fileNames
+}
+
+func (m model) current() *node {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+
+	return m.rows[m.cursor].node
+}
+
+func (m *model) refreshRows() {
+	if m.filter.Value() != "" {
+		m.rows = filteredRows(m.root, m.filter.Value())
+	} else {
+		m.rows = visibleRows(m.root)
+	}
+	m.moveCursor(0)
+}
+
+func (m *model) moveCursor(delta int) {
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+
+	// keep the cursor on screen
+	pageHeight := m.pageHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+pageHeight {
+		m.offset = m.cursor - pageHeight + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.rows)-pageHeight))
+}
+
+// rows that fit between the header and the help line
+func (m model) pageHeight() int {
+	_, v := docStyle.GetFrameSize()
+	return max(1, filters.GlobalWindowSize.Height-v-headerHeight-footerHeight)
+}
@@ -0,0 +1,153 @@
+package archive_browser
+
+import (
+	"path/filepath"
+	"sort"
+	"stone-tools/lib"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+type entry struct {
+	virtualFile     lib.MtfVirtualFile
+	compressionInfo lib.MtfCompressionInfo
+}
+
+// a directory or file in the archive, directories are implied by the virtual file names
+type node struct {
+	name     string
+	depth    int
+	parent   *node
+	children []*node
+	entry    *entry // nil for directories
+	expanded bool
+
+	// for directories these add up everything below them
+	size       uint64
+	storedSize uint64
+	fileCount  int
+}
+
+func (n *node) isDir() bool {
+	return n.entry == nil
+}
+
+// what the filter matches against, the full virtual file name for files
+func (n *node) path() string {
+	if n.parent == nil || n.parent.parent == nil {
+		return n.name
+	}
+
+	return filepath.Join(n.parent.path(), n.name)
+}
+
+func buildTree(entries []entry) *node {
+	root := &node{expanded: true, depth: -1}
+	for i := range entries {
+		parts := strings.Split(entries[i].virtualFile.FileName, string(filepath.Separator))
+
+		current := root
+		for depth, part := range parts {
+			isFile := depth == len(parts)-1
+			child := current.child(part, isFile)
+			if child == nil {
+				child = &node{name: part, depth: depth, parent: current}
+				if isFile {
+					child.entry = &entries[i]
+				}
+				current.children = append(current.children, child)
+			}
+
+			child.size += uint64(entries[i].virtualFile.TotalSize)
+			child.storedSize += uint64(entries[i].compressionInfo.StoredSize)
+			child.fileCount++
+			current = child
+		}
+		root.size += uint64(entries[i].virtualFile.TotalSize)
+		root.storedSize += uint64(entries[i].compressionInfo.StoredSize)
+		root.fileCount++
+	}
+
+	root.sortChildren()
+	return root
+}
+
+func (n *node) child(name string, isFile bool) *node {
+	for _, child := range n.children {
+		if child.name == name && child.isDir() != isFile {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// directories first, then by name ignoring case the way windows lists them
+func (n *node) sortChildren() {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		if n.children[i].isDir() != n.children[j].isDir() {
+			return n.children[i].isDir()
+		}
+		return strings.ToLower(n.children[i].name) < strings.ToLower(n.children[j].name)
+	})
+
+	for _, child := range n.children {
+		child.sortChildren()
+	}
+}
+
+// every file at or below this node
+func (n *node) files() []*node {
+	if !n.isDir() {
+		return []*node{n}
+	}
+
+	files := make([]*node, 0, n.fileCount)
+	for _, child := range n.children {
+		files = append(files, child.files()...)
+	}
+
+	return files
+}
+
+type row struct {
+	node *node
+	// set while filtering, the rune positions in the path that matched
+	matchedIndexes []int
+}
+
+// the tree as currently expanded
+func visibleRows(root *node) []row {
+	rows := make([]row, 0)
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, child := range n.children {
+			rows = append(rows, row{node: child})
+			if child.isDir() && child.expanded {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+
+	return rows
+}
+
+// a flat, best match first list of files while a filter is typed in
+func filteredRows(root *node, term string) []row {
+	files := root.files()
+	targets := make([]string, len(files))
+	for i, file := range files {
+		targets[i] = file.path()
+	}
+
+	ranks := list.DefaultFilter(term, targets)
+	rows := make([]row, len(ranks))
+	for i, rank := range ranks {
+		rows[i] = row{node: files[rank.Index], matchedIndexes: rank.MatchedIndexes}
+	}
+
+	return rows
+}
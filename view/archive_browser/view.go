@@ -0,0 +1,229 @@
+package archive_browser
+
+import (
+	"fmt"
+	"path/filepath"
+	"stone-tools/view/filters"
+	"stone-tools/view/format"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	headerHeight = 4
+	footerHeight = 2
+
+	sizeColumnWidth  = 10
+	ratioColumnWidth = 8
+
+	treeWidthPercent     = 55
+	minPreviewTotalWidth = 100
+)
+
+var (
+	docStyle      = lipgloss.NewStyle().Margin(1, 2)
+	titleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#25A065")).Padding(0, 1)
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Bold(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#25A065"))
+	matchStyle    = lipgloss.NewStyle().Underline(true)
+	previewStyle  = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, false, false, true).BorderForeground(lipgloss.Color("#626262")).PaddingLeft(1)
+	columnStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render
+)
+
+func (m model) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(filepath.Base(m.archivePath)) + "\n")
+
+	switch {
+	case m.loading:
+		s.WriteString("\nReading archive...\n")
+		return docStyle.Render(s.String())
+	case m.err != nil:
+		s.WriteString("\n" + errorStyle(fmt.Sprintf("Could not read archive: %v", m.err)) + "\n\n")
+		s.WriteString(helpStyle("esc back • q quit"))
+		return docStyle.Render(s.String())
+	}
+
+	selectedCount, selectedSize := m.selectionTotals()
+	s.WriteString(fmt.Sprintf("%d files, %s (%s stored) • %d selected, %s\n",
+		m.root.fileCount, format.Bytes(m.root.size), format.Bytes(m.root.storedSize),
+		selectedCount, format.Bytes(selectedSize)))
+	if m.filtering || m.filter.Value() != "" {
+		s.WriteString(m.filter.View() + "\n")
+	} else {
+		s.WriteString("\n")
+	}
+
+	width := m.rowWidth()
+	pageHeight := m.pageHeight()
+
+	var tree strings.Builder
+	tree.WriteString(columnStyle.Render(m.columns("Name", "Size", "Stored", "Ratio", width)))
+	for i := m.offset; i < min(len(m.rows), m.offset+pageHeight); i++ {
+		tree.WriteString("\n" + m.renderRow(m.rows[i], i == m.cursor, width))
+	}
+	for i := len(m.rows) - m.offset; i < pageHeight; i++ {
+		tree.WriteString("\n")
+	}
+
+	if m.previewVisible() {
+		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, tree.String(), m.renderPreview(pageHeight+1)) + "\n")
+	} else {
+		s.WriteString(tree.String() + "\n")
+	}
+
+	if m.filtering {
+		s.WriteString(helpStyle("enter keep filter • esc clear filter"))
+	} else {
+		s.WriteString(helpStyle("space select • a select shown • ←/→ fold • / filter • p preview • enter extract • esc back"))
+	}
+
+	return docStyle.Render(s.String())
+}
+
+func (m model) renderRow(r row, isCursor bool, width int) string {
+	n := r.node
+
+	mark := "[ ]"
+	selectedFiles := 0
+	files := n.files()
+	for _, file := range files {
+		if m.selected[file.entry.virtualFile.FileName] {
+			selectedFiles++
+		}
+	}
+	if selectedFiles == len(files) && selectedFiles > 0 {
+		mark = "[x]"
+	} else if selectedFiles > 0 {
+		mark = "[-]"
+	}
+
+	name := n.name
+	indent := ""
+	if r.matchedIndexes != nil {
+		name = highlightMatches(n.path(), r.matchedIndexes)
+	} else {
+		indent = strings.Repeat("  ", n.depth)
+		if n.isDir() {
+			fold := "▸ "
+			if n.expanded {
+				fold = "▾ "
+			}
+			name = fold + name + string(filepath.Separator)
+		}
+	}
+
+	ratio := "-"
+	switch {
+	case n.isDir() && n.size > 0:
+		ratio = fmt.Sprintf("%d%%", n.storedSize*100/n.size)
+	case !n.isDir() && n.entry.compressionInfo.Compressed && n.size > 0:
+		ratio = fmt.Sprintf("%d%%", n.storedSize*100/n.size)
+	case !n.isDir():
+		ratio = "stored"
+	}
+
+	line := m.columns(mark+" "+indent+name, format.Bytes(n.size), format.Bytes(n.storedSize), ratio, width)
+	switch {
+	case isCursor:
+		return cursorStyle.Render(line)
+	case selectedFiles > 0:
+		return selectedStyle.Render(line)
+	}
+	return line
+}
+
+// name on the left, the numbers lined up on the right
+func (m model) columns(name, size, stored, ratio string, width int) string {
+	nameWidth := max(10, width-sizeColumnWidth*2-ratioColumnWidth)
+	if lipgloss.Width(name) > nameWidth {
+		name = truncate(name, nameWidth)
+	}
+
+	return name + strings.Repeat(" ", nameWidth-lipgloss.Width(name)) +
+		fmt.Sprintf("%*s%*s%*s", sizeColumnWidth, size, sizeColumnWidth, stored, ratioColumnWidth, ratio)
+}
+
+func (m model) totalWidth() int {
+	h, _ := docStyle.GetFrameSize()
+	return max(40, filters.GlobalWindowSize.Width-h)
+}
+
+// the preview only gets a pane when there is room to leave the tree usable
+func (m model) previewVisible() bool {
+	return m.showPreview && m.totalWidth() >= minPreviewTotalWidth
+}
+
+func (m model) rowWidth() int {
+	if m.previewVisible() {
+		return m.totalWidth() * treeWidthPercent / 100
+	}
+	return m.totalWidth()
+}
+
+func (m model) renderPreview(height int) string {
+	width := m.totalWidth() - m.rowWidth() - previewStyle.GetHorizontalFrameSize()
+
+	current := m.current()
+	var body string
+	switch {
+	case current == nil:
+	case current.isDir():
+		body = fmt.Sprintf("%d files\n%s, %s stored", current.fileCount, format.Bytes(current.size), format.Bytes(current.storedSize))
+	case m.preview.loading:
+		body = "Decompressing..."
+	case m.preview.err != nil:
+		body = errorStyle(truncate(m.preview.err.Error(), width))
+	default:
+		body = m.preview.content.Render(width, height-1)
+	}
+
+	title := ""
+	if current != nil {
+		title = truncate(current.name, width)
+	}
+
+	return previewStyle.Height(height).Render(columnStyle.Render(title) + "\n" + body)
+}
+
+func (m model) selectionTotals() (int, uint64) {
+	var size uint64
+	for _, e := range m.entries {
+		if m.selected[e.virtualFile.FileName] {
+			size += uint64(e.virtualFile.TotalSize)
+		}
+	}
+
+	return len(m.selected), size
+}
+
+// keeps the end of long paths since that is the part that tells files apart
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	return "…" + string(runes[len(runes)-width+1:])
+}
+
+func highlightMatches(s string, matchedIndexes []int) string {
+	matched := make(map[int]bool, len(matchedIndexes))
+	for _, index := range matchedIndexes {
+		matched[index] = true
+	}
+
+	var builder strings.Builder
+	for i, r := range []rune(s) {
+		if matched[i] {
+			builder.WriteString(matchStyle.Render(string(r)))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
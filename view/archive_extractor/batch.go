@@ -0,0 +1,409 @@
+package archive_extractor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/filters"
+	"stone-tools/view/format"
+	"stone-tools/view/router"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	batchHeaderHeight = 7
+	batchFooterHeight = 2
+	batchBarWidth     = 24
+)
+
+var (
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Bold(true)
+	doneStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#25A065")).Render
+)
+
+type batchJobState int
+
+const (
+	jobQueued batchJobState = iota
+	jobRunning
+	jobDone
+	jobFailed
+	jobCanceled
+)
+
+type batchJob struct {
+	archivePath string
+	outputName  string
+	state       batchJobState
+	sub         chan extractProgressMsg
+	progress    extractProgress
+}
+
+type batchProgressMsg struct {
+	job int
+	msg extractProgressMsg
+}
+
+// extracts several archives, conf.Extraction.Archives of them at a time, each into its own folder
+type batchModel struct {
+	conf           config.Config
+	convertOptions lib.ConvertOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	jobs        []batchJob
+	running     int
+	cursor      int
+	offset      int
+	progress    progress.Model
+	rowProgress progress.Model
+	startTime   time.Time
+	endTime     time.Time
+}
+
+func NewBatch(conf config.Config, archivePaths []string, convertOptions lib.ConvertOptions) batchModel {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	outputNames := uniqueOutputNames(archivePaths)
+	jobs := make([]batchJob, len(archivePaths))
+	for i, archivePath := range archivePaths {
+		jobs[i] = batchJob{archivePath: archivePath, outputName: outputNames[i]}
+	}
+
+	m := batchModel{
+		conf:           conf,
+		convertOptions: convertOptions,
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		jobs:        jobs,
+		progress:    progress.New(progress.WithDefaultGradient(), progress.WithWidth(filters.GlobalWindowSize.Width-(padding*2)-4)),
+		rowProgress: progress.New(progress.WithDefaultGradient(), progress.WithWidth(batchBarWidth)),
+		startTime:   time.Now(),
+	}
+	m.claimQueued()
+
+	return m
+}
+
+// the same archive name on two discs would otherwise end up in one folder
+func uniqueOutputNames(archivePaths []string) []string {
+	counts := make(map[string]int)
+	for _, archivePath := range archivePaths {
+		counts[strings.ToLower(archiveOutputName(archivePath))]++
+	}
+
+	outputNames := make([]string, len(archivePaths))
+	for i, archivePath := range archivePaths {
+		outputName := archiveOutputName(archivePath)
+		if counts[strings.ToLower(outputName)] > 1 {
+			if isoPath, _, inIso := lib.SplitArchivePath(archivePath); inIso {
+				outputName += " (" + archiveOutputName(isoPath) + ")"
+			}
+		}
+		outputNames[i] = outputName
+	}
+
+	return outputNames
+}
+
+func (m batchModel) Init() tea.Cmd {
+	running := make([]int, 0, m.running)
+	for i, job := range m.jobs {
+		if job.state == jobRunning {
+			running = append(running, i)
+		}
+	}
+
+	return m.startJobs(running)
+}
+
+// marks as many queued jobs as running as there is room for, Init or Update then starts them
+func (m *batchModel) claimQueued() []int {
+	concurrency := max(1, m.conf.Extraction.Archives)
+
+	claimed := make([]int, 0, concurrency)
+	for i := range m.jobs {
+		if m.running >= concurrency || m.ctx.Err() != nil {
+			break
+		}
+		if m.jobs[i].state != jobQueued {
+			continue
+		}
+
+		m.jobs[i].state = jobRunning
+		m.jobs[i].sub = make(chan extractProgressMsg)
+		m.running++
+		claimed = append(claimed, i)
+	}
+
+	return claimed
+}
+
+// workers are shared out between the archives running at once
+func (m batchModel) startJobs(jobIndexes []int) tea.Cmd {
+	workerCount := max(1, m.conf.Extraction.Workers/max(1, m.conf.Extraction.Archives))
+
+	cmds := make([]tea.Cmd, 0, len(jobIndexes)*2)
+	for _, jobIndex := range jobIndexes {
+		job := m.jobs[jobIndex]
+		extract := extractArchive(m.ctx, job.sub, job.archivePath, filepath.Join(m.conf.Extraction.OutputDirectory, job.outputName), nil, workerCount, m.convertOptions)
+		cmds = append(cmds, func() tea.Msg {
+			return batchProgressMsg{job: jobIndex, msg: extract().(extractProgressMsg)}
+		}, waitForBatchProgress(jobIndex, job.sub))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func waitForBatchProgress(job int, sub chan extractProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-sub
+		if !ok {
+			return nil
+		}
+		return batchProgressMsg{job: job, msg: msg}
+	}
+}
+
+// late progress from a job can still come in while its details are open
+func (m batchModel) ReceivesInBackground(msg tea.Msg) bool {
+	_, ok := msg.(batchProgressMsg)
+	return ok
+}
+
+func (m batchModel) finished() bool {
+	for _, job := range m.jobs {
+		if job.state == jobQueued || job.state == jobRunning {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m batchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case batchProgressMsg:
+		return m.onBatchProgressMsg(msg)
+	case tea.WindowSizeMsg:
+		m.progress.Width = msg.Width - (padding * 2) - 4
+		m.moveCursor(0)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "c":
+			m.cancel()
+			for i := range m.jobs {
+				if m.jobs[i].state == jobQueued {
+					m.jobs[i].state = jobCanceled
+				}
+			}
+			if m.finished() && m.endTime.IsZero() {
+				m.endTime = time.Now()
+			}
+			return m, nil
+		case "up", "k":
+			m.moveCursor(-1)
+			return m, nil
+		case "down", "j":
+			m.moveCursor(1)
+			return m, nil
+		case "enter":
+			// the details are a snapshot, so they only open once nothing is left running
+			if !m.finished() {
+				return m, nil
+			}
+			job := m.jobs[m.cursor]
+			detail := New(m.conf, job.archivePath, nil, m.convertOptions)
+			detail.outputName = job.outputName
+			detail.extractProgress = job.progress
+			return m, router.Push(detail)
+		case "esc":
+			if m.finished() {
+				return m, router.Back()
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m batchModel) onBatchProgressMsg(msg batchProgressMsg) (tea.Model, tea.Cmd) {
+	job := &m.jobs[msg.job]
+	wasDone := job.progress.isDone
+	job.progress.update(msg.msg)
+	if wasDone {
+		// a late message from a worker, the job already finished
+		return m, nil
+	}
+	if !job.progress.isDone {
+		return m, waitForBatchProgress(msg.job, job.sub)
+	}
+
+	switch {
+	case job.progress.wasCanceled:
+		job.state = jobCanceled
+	case msg.msg.err != nil && msg.msg.totalFiles == 0:
+		// the archive itself could not be read
+		job.state = jobFailed
+	default:
+		job.state = jobDone
+	}
+	m.running--
+
+	cmd := m.startJobs(m.claimQueued())
+	if m.finished() && m.endTime.IsZero() {
+		m.endTime = time.Now()
+	}
+	return m, cmd
+}
+
+func (m *batchModel) moveCursor(delta int) {
+	m.cursor = max(0, min(len(m.jobs)-1, m.cursor+delta))
+
+	pageHeight := m.pageHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+pageHeight {
+		m.offset = m.cursor - pageHeight + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.jobs)-pageHeight))
+}
+
+func (m batchModel) pageHeight() int {
+	return max(1, filters.GlobalWindowSize.Height-batchHeaderHeight-batchFooterHeight)
+}
+
+func (m batchModel) View() string {
+	pad := strings.Repeat(" ", padding)
+
+	var s strings.Builder
+	s.WriteString("\n" + pad + titleStyle.Render("Batch Extraction") + " " + helpStyle(fmt.Sprintf("%d archives into %s, %d at a time", len(m.jobs), m.conf.Extraction.OutputDirectory, max(1, m.conf.Extraction.Archives))) + "\n\n")
+	s.WriteString(pad + m.progress.ViewAs(m.percent()) + "\n")
+	s.WriteString(pad + m.statsView() + "\n\n")
+
+	nameWidth := 0
+	for _, job := range m.jobs {
+		nameWidth = max(nameWidth, lipgloss.Width(job.outputName))
+	}
+	nameWidth = min(nameWidth, max(12, filters.GlobalWindowSize.Width/3))
+
+	pageHeight := m.pageHeight()
+	for i := m.offset; i < min(len(m.jobs), m.offset+pageHeight); i++ {
+		job := m.jobs[i]
+		name := job.outputName
+		if runes := []rune(name); len(runes) > nameWidth {
+			name = string(runes[:nameWidth-1]) + "…"
+		}
+		name += strings.Repeat(" ", max(0, nameWidth-lipgloss.Width(name)))
+
+		cursor := "  "
+		if i == m.cursor {
+			cursor = cursorStyle.Render("> ")
+			name = cursorStyle.Render(name)
+		}
+		s.WriteString(pad + cursor + name + "  " + m.rowProgress.ViewAs(job.progress.percent) + "  " + jobStatus(job) + "\n")
+	}
+
+	help := "↑/↓ move • c cancel the rest"
+	if m.finished() {
+		help = fmt.Sprintf("Batch took %s • ↑/↓ move • enter details and log • esc back", m.endTime.Sub(m.startTime).Round(time.Millisecond))
+	}
+	s.WriteString("\n" + pad + helpStyle(help))
+	return s.String()
+}
+
+// finished archives count in full, the running ones by how far along they are
+func (m batchModel) percent() float64 {
+	if len(m.jobs) == 0 {
+		return 1
+	}
+
+	total := 0.0
+	for _, job := range m.jobs {
+		switch job.state {
+		case jobRunning, jobCanceled:
+			total += job.progress.percent
+		case jobDone, jobFailed:
+			total += 1
+		}
+	}
+
+	return total / float64(len(m.jobs))
+}
+
+func (m batchModel) statsView() string {
+	finished, failed, errorCount := 0, 0, 0
+	var extractedFiles, totalFiles float64
+	var readBytes, writtenBytes int64
+	var readRate float64
+	for _, job := range m.jobs {
+		switch job.state {
+		case jobDone:
+			finished++
+		case jobFailed:
+			finished++
+			failed++
+		case jobRunning:
+			rate, _ := job.progress.throughput()
+			readRate += rate
+		}
+		errorCount += len(job.progress.errors)
+		extractedFiles += job.progress.latest.extractedFiles
+		totalFiles += job.progress.latest.totalFiles
+		readBytes += job.progress.latest.readBytes
+		writtenBytes += job.progress.latest.writtenBytes
+	}
+
+	stats := fmt.Sprintf("%d/%d archives • %d/%d files so far • %s read • %s written",
+		finished, len(m.jobs), int(extractedFiles), int(totalFiles), format.Bytes(uint64(readBytes)), format.Bytes(uint64(writtenBytes)))
+	if !m.finished() {
+		stats += fmt.Sprintf(" • %s/s", format.Bytes(uint64(readRate)))
+	}
+	if failed > 0 || errorCount > 0 {
+		stats += errorStyle(fmt.Sprintf(" • %d archives failed, %d errors", failed, errorCount))
+	}
+
+	return stats
+}
+
+func jobStatus(job batchJob) string {
+	p := job.progress
+	switch job.state {
+	case jobQueued:
+		return helpStyle("queued")
+	case jobRunning:
+		status := fmt.Sprintf("%d/%d files", int(p.latest.extractedFiles), int(p.latest.totalFiles))
+		if readRate, _ := p.throughput(); readRate > 0 {
+			status += fmt.Sprintf(" • %s/s", format.Bytes(uint64(readRate)))
+		}
+		if eta, ok := p.eta(); ok {
+			status += " • ETA " + eta.Round(time.Second).String()
+		}
+		return status
+	case jobFailed:
+		if len(p.errors) > 0 {
+			return errorStyle(strings.TrimSpace(p.errors[0]))
+		}
+		return errorStyle("failed")
+	case jobCanceled:
+		return warningStyle("canceled")
+	}
+
+	status := doneStyle(fmt.Sprintf("done, %d files", int(p.latest.extractedFiles)))
+	if len(p.errors) > 0 {
+		status += errorStyle(fmt.Sprintf(", %d errors", len(p.errors)))
+	}
+	return status
+}
@@ -6,29 +6,44 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"os"
 	"path/filepath"
+	"sort"
 	"stone-tools/lib"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// how often the byte counters are sent while files are still being decompressed
+const progressInterval = 100 * time.Millisecond
+
 func (m model) Init() tea.Cmd {
+	// a batch opens its finished jobs here only to show how they went
+	if m.extractProgress.isDone {
+		return nil
+	}
+
 	return tea.Batch(
-		extractArchive(m.ctx, m.sub, m.archivePath), // asynchronously start extracting the mtf file
-		waitForProgress(m.sub),                      // wait for results
+		extractArchive(m.ctx, m.sub, m.archivePath, m.outputDirectory(), m.fileNames, m.conf.Extraction.Workers, m.convertOptions), // asynchronously start extracting the mtf file
+		waitForProgress(m.sub), // wait for results
 	)
 }
 
 type extractProgressMsg struct {
-	// aggregate metrics
-	extractedFiles float64
-	totalFiles     float64
-	isDone         bool
-	wasCanceled    bool
+	// aggregate metrics, snapshots can arrive out of order so sequence says which is newest
+	sequence          int
+	extractedFiles    float64
+	totalFiles        float64
+	readBytes         int64 // stored bytes taken out of the archive, compressed or not
+	totalReadBytes    int64
+	writtenBytes      int64 // decompressed bytes handed to the writers
+	totalWrittenBytes int64
+	active            []activeExtraction
+	isDone            bool
+	wasCanceled       bool
 
 	// latest on-the-fly metric
 	time       time.Time
@@ -37,167 +52,271 @@ type extractProgressMsg struct {
 	errorCount int
 }
 
-func extractArchive(ctx context.Context, sub chan extractProgressMsg, mtfFilePath string) tea.Cmd {
+type activeExtraction struct {
+	worker     int
+	fileName   string
+	readBytes  int64
+	storedSize int64
+}
+
+// the state shared by the workers, every message is a snapshot of it
+type extractTracker struct {
+	mutex             sync.Mutex
+	sequence          int
+	extractedFiles    int
+	totalFiles        int
+	errorCount        int
+	readBytes         int64 // of finished files, the active ones add their own counts
+	totalReadBytes    int64
+	writtenBytes      int64
+	totalWrittenBytes int64
+	active            map[int]*trackedFile
+}
+
+type trackedFile struct {
+	fileName   string
+	storedSize int64
+	readBytes  atomic.Int64 // bumped by the decompressor as it reads, without taking the lock
+}
+
+// counts what is read through it so progress moves while a big file is still decompressing
+type countingReader struct {
+	io.ReadSeeker
+	count *atomic.Int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadSeeker.Read(p)
+	r.count.Add(int64(n))
+	return n, err
+}
+
+func (t *extractTracker) snapshot(message string, err error) extractProgressMsg {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.sequence++
+	msg := extractProgressMsg{
+		sequence:          t.sequence,
+		extractedFiles:    float64(t.extractedFiles),
+		totalFiles:        float64(t.totalFiles),
+		readBytes:         t.readBytes,
+		totalReadBytes:    t.totalReadBytes,
+		writtenBytes:      t.writtenBytes,
+		totalWrittenBytes: t.totalWrittenBytes,
+		active:            make([]activeExtraction, 0, len(t.active)),
+
+		time:       time.Now().UTC(),
+		message:    message,
+		err:        err,
+		errorCount: t.errorCount,
+	}
+	for worker, file := range t.active {
+		// headers and the trailing crc are read too, never count past the entry itself
+		readBytes := min(file.readBytes.Load(), file.storedSize)
+		msg.readBytes += readBytes
+		msg.active = append(msg.active, activeExtraction{worker: worker, fileName: file.fileName, readBytes: readBytes, storedSize: file.storedSize})
+	}
+	sort.Slice(msg.active, func(i, j int) bool {
+		return msg.active[i].worker < msg.active[j].worker
+	})
+
+	return msg
+}
+
+func (t *extractTracker) start(worker int, virtualFile lib.MtfVirtualFile, storedSize int64) *trackedFile {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	file := &trackedFile{fileName: virtualFile.FileName, storedSize: storedSize}
+	t.active[worker] = file
+	return file
+}
+
+// a failed file still counts as read so the bar reaches the end
+func (t *extractTracker) finish(worker int, writtenBytes int64, failed bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.readBytes += t.active[worker].storedSize
+	delete(t.active, worker)
+	if failed {
+		t.errorCount++
+		return
+	}
+	t.extractedFiles++
+	t.writtenBytes += writtenBytes
+}
+
+// canceled between reading and writing, so it is neither extracted nor an error
+func (t *extractTracker) abandon(worker int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.active, worker)
+}
+
+func extractArchive(ctx context.Context, sub chan extractProgressMsg, mtfFilePath, outputDirectory string, fileNames []string, workerCount int, convertOptions lib.ConvertOptions) tea.Cmd {
 	return func() tea.Msg {
-		mtfFileData, err := os.ReadFile(mtfFilePath)
+		defer close(sub)
+
+		mtfFileData, err := lib.ReadArchiveFile(mtfFilePath)
 		if err != nil {
-			sub <- extractProgressMsg{
-				extractedFiles: 0,
-				totalFiles:     0,
-				isDone:         true,
+			return extractProgressMsg{
+				isDone: true,
 
 				time:       time.Now().UTC(),
 				message:    fmt.Sprintf("Error opening file: %v", err),
 				errorCount: 1,
 				err:        err,
 			}
-
-			return nil
 		}
 		mtfFile := bytes.NewReader(mtfFileData)
 
 		archive, err := lib.ScanMtfFile(mtfFile)
 		if err != nil {
-			sub <- extractProgressMsg{
-				extractedFiles: 0,
-				totalFiles:     0,
-				isDone:         true,
+			return extractProgressMsg{
+				isDone: true,
 
 				time:       time.Now().UTC(),
 				message:    fmt.Sprintf("Error scanning mtf file: %v", err),
 				errorCount: 1,
 				err:        err,
 			}
-			return nil
 		}
 
-		canceled := false
-		errorCount := 0
-		extractedFiles := 0
-		totalFiles := len(archive.VirtualFiles)
-		sub <- extractProgressMsg{
-			extractedFiles: float64(extractedFiles),
-			totalFiles:     float64(totalFiles),
+		virtualFiles := selectVirtualFiles(archive.VirtualFiles, fileNames)
 
-			time:       time.Now().UTC(),
-			errorCount: errorCount,
+		// compressed entries take up less of the archive than they extract to, so both totals are needed up front
+		tracker := &extractTracker{totalFiles: len(virtualFiles), active: make(map[int]*trackedFile)}
+		storedSizes := make([]int64, len(virtualFiles))
+		for i, virtualFile := range virtualFiles {
+			storedSizes[i] = int64(virtualFile.TotalSize)
+			if info, err := lib.ReadCompressionInfo(mtfFile, virtualFile); err == nil {
+				storedSizes[i] = int64(info.StoredSize)
+			}
+			tracker.totalReadBytes += storedSizes[i]
+			tracker.totalWrittenBytes += int64(virtualFile.TotalSize)
 		}
+		sub <- tracker.snapshot("", nil)
+
+		ticking := make(chan struct{})
+		ticked := make(chan struct{})
+		go func() {
+			defer close(ticked)
+			ticker := time.NewTicker(progressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					select {
+					case sub <- tracker.snapshot("", nil):
+					default:
+						// a file just finished and sent one already
+					}
+				case <-ticking:
+					return
+				}
+			}
+		}()
 
 		var wg sync.WaitGroup
-		outputDirectory := filepath.Join("out", strings.TrimSuffix(filepath.Base(mtfFilePath), filepath.Ext(mtfFilePath)))
-		for _, virtualFile := range archive.VirtualFiles {
+		workers := make(chan int, max(1, workerCount))
+		for worker := range cap(workers) {
+			workers <- worker + 1
+		}
+	queueing:
+		for i, virtualFile := range virtualFiles {
+			var worker int
+			select {
+			case worker = <-workers:
+			case <-ctx.Done():
+				break queueing
+			}
+
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				isCanceled := checkForCancelation(ctx, sub)
-				if isCanceled {
-					canceled = true
+				defer func() { workers <- worker }()
+				if ctx.Err() != nil {
 					return
 				}
 
+				file := tracker.start(worker, virtualFile, storedSizes[i])
 				section := io.NewSectionReader(mtfFile, 0, math.MaxInt64)
-				extractedFile, err := lib.ExtractVirtualFile(section, virtualFile)
+				extractedFile, err := lib.ExtractVirtualFile(countingReader{ReadSeeker: section, count: &file.readBytes}, virtualFile)
 				if err != nil {
-					errorCount++
-					sub <- extractProgressMsg{
-						extractedFiles: float64(extractedFiles),
-						totalFiles:     float64(totalFiles),
-
-						time:       time.Now().UTC(),
-						message:    fmt.Sprintf("Error extracting file `%s`: %+v\r\n", virtualFile.FileName, err),
-						errorCount: errorCount,
-						err:        err,
-					}
+					tracker.finish(worker, 0, true)
+					sub <- tracker.snapshot(fmt.Sprintf("Error extracting file `%s`: %+v\r\n", virtualFile.FileName, err), err)
 					return
 				}
 
-				isCanceled = checkForCancelation(ctx, sub)
-				if isCanceled {
-					canceled = true
+				if ctx.Err() != nil {
+					tracker.abandon(worker)
 					return
 				}
 
 				writePath := filepath.Join(outputDirectory, virtualFile.FileName)
-				os.MkdirAll(filepath.Dir(writePath), os.ModePerm)
-				err = os.WriteFile(writePath, extractedFile, os.ModePerm)
+				writtenPaths, err := lib.WriteExtractedFile(writePath, extractedFile, convertOptions)
 				if err != nil {
-					errorCount++
-					sub <- extractProgressMsg{
-						extractedFiles: float64(extractedFiles),
-						totalFiles:     float64(totalFiles),
-
-						time:       time.Now().UTC(),
-						message:    fmt.Sprintf("Error writing extracted file `%s`: %+v\r\n", virtualFile.FileName, err),
-						errorCount: errorCount,
-						err:        err,
-					}
+					tracker.finish(worker, 0, true)
+					sub <- tracker.snapshot(fmt.Sprintf("Error writing extracted file `%s`: %+v\r\n", virtualFile.FileName, err), err)
 					return
 				}
 
-				extractedFiles++
-				sub <- extractProgressMsg{
-					extractedFiles: float64(extractedFiles),
-					totalFiles:     float64(totalFiles),
-
-					time:       time.Now().UTC(),
-					message:    fmt.Sprintf("%s (%d bytes)", writePath, len(extractedFile)),
-					errorCount: errorCount,
-				}
-
-				isCanceled = checkForCancelation(ctx, sub)
-				if isCanceled {
-					canceled = true
-					return
-				}
+				tracker.finish(worker, int64(len(extractedFile)), false)
+				sub <- tracker.snapshot(fmt.Sprintf("%s (%d bytes)", strings.Join(writtenPaths, ", "), len(extractedFile)), nil)
 			}()
 		}
 
 		wg.Wait()
-		if canceled {
-			return nil
-		}
+		close(ticking)
+		<-ticked
 
-		return extractProgressMsg{
-			extractedFiles: float64(totalFiles),
-			totalFiles:     float64(totalFiles),
-			isDone:         true,
-
-			time:       time.Now().UTC(),
-			message:    fmt.Sprintf("Complete. Total files read %d and total errors %d.", totalFiles, errorCount),
-			errorCount: errorCount,
+		final := tracker.snapshot("", nil)
+		final.isDone = true
+		if ctx.Err() != nil {
+			final.wasCanceled = true
+			final.message = "Extraction canceled."
+			return final
 		}
+
+		final.message = fmt.Sprintf("Complete. Total files read %d and total errors %d.", tracker.totalFiles, final.errorCount)
+		return final
 	}
 }
 
-func checkForCancelation(ctx context.Context, sub chan extractProgressMsg) bool {
-	if ctx.Err() != nil {
-		sub <- extractProgressMsg{
-			isDone:      true,
-			wasCanceled: true,
+// the folder an archive is extracted into, named after the archive itself
+func archiveOutputName(archivePath string) string {
+	return strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))
+}
 
-			time:    time.Now().UTC(),
-			message: "Extraction canceled.",
-		}
-		return true
+func selectVirtualFiles(virtualFiles []lib.MtfVirtualFile, fileNames []string) []lib.MtfVirtualFile {
+	if fileNames == nil {
+		return virtualFiles
+	}
+
+	wanted := make(map[string]bool, len(fileNames))
+	for _, fileName := range fileNames {
+		wanted[fileName] = true
 	}
-	select {
-	case <-ctx.Done():
-		sub <- extractProgressMsg{
-			isDone:      true,
-			wasCanceled: true,
-
-			time:    time.Now().UTC(),
-			message: "Extraction canceled.",
+
+	selected := make([]lib.MtfVirtualFile, 0, len(fileNames))
+	for _, virtualFile := range virtualFiles {
+		if wanted[virtualFile.FileName] {
+			selected = append(selected, virtualFile)
 		}
-		return true
-	default:
 	}
 
-	return false
+	return selected
 }
 
 func waitForProgress(sub chan extractProgressMsg) tea.Cmd {
 	return func() tea.Msg {
-		return extractProgressMsg(<-sub)
+		msg, ok := <-sub
+		if !ok {
+			return nil
+		}
+		return msg
 	}
 }
@@ -0,0 +1,182 @@
+package archive_extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stone-tools/view/filters"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lines taken up around the log itself: title, filter, status and help
+const logChromeHeight = 6
+
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityWarning
+	severityError
+)
+
+var severityNames = []string{"info", "warning", "error"}
+
+var (
+	titleStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#25A065")).Padding(0, 1)
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Render
+)
+
+func (s severity) String() string {
+	return severityNames[s]
+}
+
+func (s severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type logEntry struct {
+	Time     time.Time `json:"time"`
+	Severity severity  `json:"severity"`
+	Message  string    `json:"message"`
+}
+
+func (e logEntry) String() string {
+	return fmt.Sprintf("%s %-7s %s", e.Time.Local().Format("15:04:05.000"), strings.ToUpper(e.Severity.String()), e.Message)
+}
+
+// every message from the run, scrollable and filtered down to a minimum severity
+type logView struct {
+	visible     bool
+	minSeverity severity
+	viewport    viewport.Model
+	status      string
+	statusErr   bool
+}
+
+func newLogView() logView {
+	return logView{viewport: viewport.New(logWidth(), logHeight())}
+}
+
+func logWidth() int {
+	return max(20, filters.GlobalWindowSize.Width-(padding*2))
+}
+
+func logHeight() int {
+	return max(3, filters.GlobalWindowSize.Height-logChromeHeight)
+}
+
+// keeps following the end of the log unless it was scrolled away from
+func (l *logView) refresh(entries []logEntry) {
+	following := l.viewport.AtBottom()
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Severity < l.minSeverity {
+			continue
+		}
+		switch entry.Severity {
+		case severityError:
+			lines = append(lines, errorStyle(entry.String()))
+		case severityWarning:
+			lines = append(lines, warningStyle(entry.String()))
+		default:
+			lines = append(lines, entry.String())
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, helpStyle("Nothing logged at this level."))
+	}
+
+	l.viewport.SetContent(strings.Join(lines, "\n"))
+	if following {
+		l.viewport.GotoBottom()
+	}
+}
+
+func (m model) updateLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "l":
+		m.log.visible = false
+		return m, nil
+	case "tab":
+		m.log.minSeverity = (m.log.minSeverity + 1) % severity(len(severityNames))
+		m.log.refresh(m.extractProgress.log)
+		return m, nil
+	case "e", "E":
+		path, err := m.exportLog(msg.String() == "E")
+		m.log.status, m.log.statusErr = "Saved the log to "+path, false
+		if err != nil {
+			m.log.status, m.log.statusErr = fmt.Sprintf("Error saving the log: %v", err), true
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.log.viewport, cmd = m.log.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m model) logView() string {
+	pad := strings.Repeat(" ", padding)
+
+	filter := make([]string, 0, len(severityNames))
+	for s := range severity(len(severityNames)) {
+		name := s.String()
+		if s >= m.log.minSeverity {
+			name = "[" + name + "]"
+		}
+		filter = append(filter, name)
+	}
+
+	status := helpStyle(fmt.Sprintf("%d entries", len(m.extractProgress.log)))
+	if m.log.status != "" {
+		status = m.log.status
+		if m.log.statusErr {
+			status = errorStyle(status)
+		}
+	}
+
+	return "\n" +
+		pad + titleStyle.Render("Extraction Log") + " " + filepath.Base(m.archivePath) + "\n" +
+		pad + helpStyle("showing ") + strings.Join(filter, " ") + "\n" +
+		lipgloss.NewStyle().MarginLeft(padding).Render(m.log.viewport.View()) + "\n" +
+		pad + status + "\n" +
+		pad + helpStyle("↑/↓ scroll • tab severity • e save as text • E save as json • esc close")
+}
+
+// saved beside the archive's own output folder so it does not end up mixed in with the game files
+func (m model) exportLog(asJson bool) (string, error) {
+	extension := ".txt"
+	if asJson {
+		extension = ".json"
+	}
+	path := filepath.Join(m.conf.Extraction.OutputDirectory, fmt.Sprintf("%s-log-%s%s", m.outputName, time.Now().Format("20060102-150405"), extension))
+
+	var data []byte
+	if asJson {
+		var err error
+		data, err = json.MarshalIndent(m.extractProgress.log, "", "  ")
+		if err != nil {
+			return path, err
+		}
+	} else {
+		lines := make([]string, len(m.extractProgress.log))
+		for i, entry := range m.extractProgress.log {
+			lines[i] = entry.String()
+		}
+		data = []byte(strings.Join(lines, "\r\n") + "\r\n")
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return path, err
+	}
+
+	return path, os.WriteFile(path, data, 0o644)
+}
@@ -2,7 +2,12 @@ package archive_extractor
 
 import (
 	"context"
+	"path/filepath"
+	"stone-tools/config"
+	"stone-tools/lib"
 	"stone-tools/view/filters"
+	"stone-tools/view/router"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
@@ -12,6 +17,8 @@ import (
 
 const (
 	padding = 2
+
+	throughputWindow = 3 * time.Second
 )
 
 var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
@@ -19,9 +26,11 @@ var helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
 var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render
 
 type model struct {
-	previousModel tea.Model
-	rootPath      string
-	archivePath   string
+	conf           config.Config
+	archivePath    string
+	outputName     string   // folder inside the output directory, the archive name unless a batch had two alike
+	fileNames      []string // only extract these virtual files, nil for everything
+	convertOptions lib.ConvertOptions
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -29,28 +38,47 @@ type model struct {
 	sub             chan extractProgressMsg
 	progress        progress.Model
 	extractProgress extractProgress
+	log             logView
 }
 
-func New(previousModel tea.Model, rootPath, archivePath string) model {
+func New(conf config.Config, archivePath string, fileNames []string, convertOptions lib.ConvertOptions) model {
 	ctx, cancel := context.WithCancel(context.Background())
 	return model{
-		previousModel: previousModel,
-		rootPath:      rootPath,
-		archivePath:   archivePath,
+		conf:           conf,
+		archivePath:    archivePath,
+		outputName:     archiveOutputName(archivePath),
+		fileNames:      fileNames,
+		convertOptions: convertOptions,
 
 		ctx:    ctx,
 		cancel: cancel,
 
 		sub:      make(chan extractProgressMsg),
 		progress: progress.New(progress.WithDefaultGradient(), progress.WithWidth(filters.GlobalWindowSize.Width-(padding*2)-4)),
+		log:      newLogView(),
 	}
 }
 
+func (m model) outputDirectory() string {
+	return filepath.Join(m.conf.Extraction.OutputDirectory, m.outputName)
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.log.visible {
+			return m.updateLog(msg)
+		}
+		if msg.String() == "l" {
+			m.log.visible = true
+			m.log.status = ""
+			m.log.refresh(m.extractProgress.log)
+			m.log.viewport.GotoBottom()
+			return m, nil
+		}
+
 		if m.extractProgress.isDone {
-			return m.previousModel, nil
+			return m, router.Back()
 		}
 
 		if msg.String() == "c" {
@@ -61,6 +89,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.onExtractProgressMsg(msg)
 	case tea.WindowSizeMsg:
 		m.progress.Width = msg.Width - (padding * 2) - 4
+		m.log.viewport.Width = logWidth()
+		m.log.viewport.Height = logHeight()
 		return m, nil
 	default:
 		return m, nil
@@ -69,6 +99,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) onExtractProgressMsg(msg extractProgressMsg) (tea.Model, tea.Cmd) {
 	m.extractProgress.update(msg)
+	if m.log.visible {
+		m.log.refresh(m.extractProgress.log)
+	}
 	if m.extractProgress.isDone {
 		// break the wait loop.
 		return m, nil
@@ -85,12 +118,41 @@ type extractProgress struct {
 	errors       []string
 	earliestTime time.Time
 	latestTime   time.Time
+	log          []logEntry // everything in the order it arrived, unlike the lists above
+
+	// the newest snapshot of the byte counters and what each worker is on
+	latest  extractProgressMsg
+	samples []progressSample
+}
+
+type progressSample struct {
+	time         time.Time
+	readBytes    int64
+	writtenBytes int64
 }
 
 func (p *extractProgress) update(msg extractProgressMsg) {
-	p.updatePercent(msg.extractedFiles / msg.totalFiles)
+	if msg.sequence >= p.latest.sequence {
+		p.latest = msg
+		p.addSample(msg)
+		if msg.totalReadBytes > 0 {
+			p.updatePercent(float64(msg.readBytes) / float64(msg.totalReadBytes))
+		} else if msg.totalFiles > 0 {
+			p.updatePercent(msg.extractedFiles / msg.totalFiles)
+		}
+	}
 	p.updateTiming(msg.time)
 
+	if msg.message != "" {
+		entry := logEntry{Time: msg.time, Severity: severityInfo, Message: strings.TrimSpace(msg.message)}
+		if msg.err != nil {
+			entry.Severity = severityError
+		} else if msg.wasCanceled {
+			entry.Severity = severityWarning
+		}
+		p.log = append(p.log, entry)
+	}
+
 	if msg.message != "" && (len(p.messages) == 0 || p.messages[0] != msg.message) {
 		if msg.err != nil {
 			p.errors = append([]string{msg.message}, p.errors...)
@@ -102,12 +164,50 @@ func (p *extractProgress) update(msg extractProgressMsg) {
 	// specifically checking for true to avoid ever setting these back to false due to async hilarity
 	if msg.isDone {
 		p.isDone = true
+		p.latest.active = nil
+		if !msg.wasCanceled {
+			p.percent = 1.0
+		}
 	}
 	if msg.wasCanceled {
 		p.wasCanceled = true
 	}
 }
 
+// only the last few seconds count towards the speed so it follows big and small files
+func (p *extractProgress) addSample(msg extractProgressMsg) {
+	p.samples = append(p.samples, progressSample{time: msg.time, readBytes: msg.readBytes, writtenBytes: msg.writtenBytes})
+	for len(p.samples) > 2 && msg.time.Sub(p.samples[0].time) > throughputWindow {
+		p.samples = p.samples[1:]
+	}
+}
+
+// bytes per second read from the archive and written out
+func (p *extractProgress) throughput() (float64, float64) {
+	if len(p.samples) < 2 {
+		return 0, 0
+	}
+
+	first, last := p.samples[0], p.samples[len(p.samples)-1]
+	seconds := last.time.Sub(first.time).Seconds()
+	if seconds <= 0 {
+		return 0, 0
+	}
+
+	return float64(last.readBytes-first.readBytes) / seconds, float64(last.writtenBytes-first.writtenBytes) / seconds
+}
+
+// false until there is a speed to go by
+func (p *extractProgress) eta() (time.Duration, bool) {
+	readRate, _ := p.throughput()
+	if readRate <= 0 || p.isDone {
+		return 0, false
+	}
+
+	remaining := float64(p.latest.totalReadBytes - p.latest.readBytes)
+	return time.Duration(remaining / readRate * float64(time.Second)), true
+}
+
 func (p *extractProgress) timeTaken() time.Duration {
 	return p.latestTime.Sub(p.earliestTime)
 }
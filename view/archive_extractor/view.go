@@ -2,10 +2,16 @@ package archive_extractor
 
 import (
 	"fmt"
+	"stone-tools/view/format"
 	"strings"
+	"time"
 )
 
 func (m model) View() string {
+	if m.log.visible {
+		return m.logView()
+	}
+
 	pad := strings.Repeat(" ", padding)
 	listing := ""
 	if len(m.extractProgress.messages) > 0 {
@@ -19,19 +25,63 @@ func (m model) View() string {
 		errors = "\n\n" + pad + "- " + strings.Join(m.extractProgress.errors[:errorSize], "\n"+pad+"- ")
 	}
 
-	helpMessage := "Extraction in progress (press c to cancel)"
+	helpMessage := "Extraction in progress (press c to cancel, l for the full log)"
 	if m.extractProgress.isDone {
 		prefix := "Extraction took %s"
 		if m.extractProgress.wasCanceled {
 			prefix = "Extraction was canceled after %s"
 		}
 
-		helpMessage = fmt.Sprintf(prefix+", Press any key to return", m.extractProgress.timeTaken())
+		helpMessage = fmt.Sprintf(prefix+", Press l for the full log or any other key to return", m.extractProgress.timeTaken())
 	}
 
 	return "\n" +
-		pad + m.progress.ViewAs(m.extractProgress.percent) + "\n\n" +
+		pad + m.progress.ViewAs(m.extractProgress.percent) + "\n" +
+		pad + m.statsView() + "\n" +
+		m.activeView(pad) + "\n" +
 		pad + helpStyle(helpMessage) +
 		listing + "\n" +
 		errorStyle(errors)
 }
+
+func (m model) statsView() string {
+	p := m.extractProgress
+	stats := fmt.Sprintf("%d/%d files • %s of %s read • %s written",
+		int(p.latest.extractedFiles), int(p.latest.totalFiles),
+		format.Bytes(uint64(p.latest.readBytes)), format.Bytes(uint64(p.latest.totalReadBytes)),
+		format.Bytes(uint64(p.latest.writtenBytes)))
+
+	if p.isDone {
+		if seconds := p.timeTaken().Seconds(); seconds > 0 {
+			stats += fmt.Sprintf(" • %s/s on average", format.Bytes(uint64(float64(p.latest.readBytes)/seconds)))
+		}
+		return stats
+	}
+
+	readRate, writeRate := p.throughput()
+	stats += fmt.Sprintf(" • %s/s read, %s/s written", format.Bytes(uint64(readRate)), format.Bytes(uint64(writeRate)))
+	if eta, ok := p.eta(); ok {
+		stats += " • ETA " + eta.Round(time.Second).String()
+	}
+
+	return stats
+}
+
+// one line per busy worker, idle ones are left out
+func (m model) activeView(pad string) string {
+	if len(m.extractProgress.latest.active) == 0 {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString(pad + helpStyle("Currently extracting:") + "\n")
+	for _, active := range m.extractProgress.latest.active {
+		percent := 0
+		if active.storedSize > 0 {
+			percent = int(active.readBytes * 100 / active.storedSize)
+		}
+		s.WriteString(fmt.Sprintf("%s  #%-2d %s (%d%% of %s)\n", pad, active.worker, active.fileName, percent, format.Bytes(uint64(active.storedSize))))
+	}
+
+	return s.String()
+}
@@ -1,13 +1,19 @@
 package archive_picker
 
 import (
-	"io/fs"
+	"fmt"
 	"path/filepath"
 	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/archive_browser"
 	"stone-tools/view/archive_extractor"
+	"stone-tools/view/content_search"
 	"stone-tools/view/filters"
-	"strings"
+	"stone-tools/view/router"
+	"stone-tools/view/search"
+	"stone-tools/view/settings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -19,35 +25,60 @@ type item struct {
 	FileName string
 	Desc     string
 	Path     string
+	Selected bool
+
+	metadata *archiveMetadata
 }
 
 func (i item) Title() string       { return i.FileName }
-func (i item) Description() string { return i.Desc }
 func (i item) FilterValue() string { return i.FileName }
 
+// the mark goes on the description so filter highlighting on the title still lines up
+func (i item) Description() string {
+	description := i.metadata.describe(i.Path)
+	if i.Desc != "" {
+		description += " • " + i.Desc
+	}
+	if i.Selected {
+		return "✓ " + description
+	}
+	return description
+}
+
 type model struct {
 	conf config.Config
 	list list.Model
+
+	enabledConverters map[string]bool
+	replaceRaw        bool
+
+	metadata     *archiveMetadata
+	sortColumn   sortColumn
+	sortReversed bool
 }
 
 func New(conf config.Config) model {
+	metadata := newArchiveMetadata()
 	var listItems []list.Item
-	filepath.WalkDir(conf.DarkstoneDirectory, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".mtf") {
-			desc := "- an mtf archive"
-			listItems = append(listItems, item{FileName: filepath.Base(path), Desc: desc, Path: path})
+	for _, archivePath := range lib.FindArchives(conf.DarkstoneDirectory) {
+		fileName, desc := filepath.Base(archivePath), ""
+		if isoPath, innerPath, inIso := lib.SplitArchivePath(archivePath); inIso {
+			// cd images are listed by the archives on them
+			fileName, desc = filepath.Base(innerPath), "inside "+filepath.Base(isoPath)
 		}
-		return nil
-	})
+		listItems = append(listItems, item{FileName: fileName, Desc: desc, Path: archivePath, metadata: metadata})
+	}
 
 	m := model{
-		conf: conf,
-		list: list.New(listItems, list.NewDefaultDelegate(), 0, 0),
+		list:     list.New(listItems, list.NewDefaultDelegate(), 0, 0),
+		metadata: metadata,
 	}
 	m.list.Title = "MTF Archives"
+	// esc goes back, q quits and ? opens the help through the router instead of the list's own
+	m.list.KeyMap.Quit.SetEnabled(false)
+	m.list.KeyMap.ShowFullHelp.SetEnabled(false)
+	m.list.KeyMap.CloseFullHelp.SetEnabled(false)
+	m.applyConfig(conf)
 
 	h, v := docStyle.GetFrameSize()
 	m.list.SetSize(filters.GlobalWindowSize.Width-h, filters.GlobalWindowSize.Height-v)
@@ -55,20 +86,98 @@ func New(conf config.Config) model {
 	return m
 }
 
+// entry counts, sizes and hashes are read in the background, the list fills in as they arrive
 func (m model) Init() tea.Cmd {
-	return nil
+	cmds := make([]tea.Cmd, 0, len(m.list.Items()))
+	for _, archivePath := range m.archivePaths() {
+		cmds = append(cmds, loadMetadata(m.metadata, archivePath))
+	}
+
+	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		if key.Matches(msg, router.Keys.Back) && m.list.FilterState() == list.Unfiltered {
+			// back to picking an install when that is where this came from
+			return m, router.Back()
+		}
+
 		switch msg.String() {
-		case "ctrl+c", "q":
-			return m, tea.Quit
 		case "enter":
-			nextView := archive_extractor.New(m, m.conf.DarkstoneDirectory, m.list.SelectedItem().(item).Path)
+			if selected, ok := m.list.SelectedItem().(item); ok {
+				return m, router.Push(archive_browser.New(m.conf, selected.Path, m.convertOptions()))
+			}
+		case "x":
+			if selectedPaths := m.selectedPaths(); len(selectedPaths) > 0 {
+				return m, router.Push(archive_extractor.NewBatch(m.conf, selectedPaths, m.convertOptions()))
+			}
+			if selected, ok := m.list.SelectedItem().(item); ok {
+				return m, router.Push(archive_extractor.New(m.conf, selected.Path, nil, m.convertOptions()))
+			}
+		case " ":
+			if selected, ok := m.list.SelectedItem().(item); ok {
+				cmd := m.setSelected(selected.Path, !selected.Selected)
+				m.updateConverterHelp()
+				return m, cmd
+			}
+		case "a":
+			// selects everything shown, or clears the selection when that is already the case
+			selectAll := false
+			for _, listItem := range m.list.VisibleItems() {
+				selectAll = selectAll || !listItem.(item).Selected
+			}
+			cmds := make([]tea.Cmd, 0)
+			for _, listItem := range m.list.VisibleItems() {
+				cmds = append(cmds, m.setSelected(listItem.(item).Path, selectAll))
+			}
+			m.updateConverterHelp()
+			return m, tea.Batch(cmds...)
+		case "s":
+			return m, router.Push(settings.New(m.conf))
+		case "f":
+			return m, router.Push(search.New(m.conf, m.archivePaths(), m.convertOptions()))
+		case "g":
+			return m, router.Push(content_search.New(m.conf, m.archivePaths(), m.convertOptions()))
+		case "o":
+			m.sortColumn = (m.sortColumn + 1) % sortColumn(len(sortColumnNames))
+			m.sortReversed = false
+			m.updateConverterHelp()
+			return m, m.sortItems()
+		case "O":
+			m.sortReversed = !m.sortReversed
+			return m, m.sortItems()
+		case "r":
+			m.replaceRaw = !m.replaceRaw
+			m.updateConverterHelp()
+			return m, nil
+		}
+
+		for i, converter := range lib.Converters {
+			if msg.String() == converterKey(i) {
+				m.enabledConverters[converter.Name] = !m.enabledConverters[converter.Name]
+				m.updateConverterHelp()
+				return m, nil
+			}
+		}
+	case metadataLoadedMsg:
+		if m.sortColumn != sortName {
+			return m, m.sortItems()
+		}
+		return m, nil
+	case settings.SavedMsg:
+		if msg.Config.DarkstoneDirectory != m.conf.DarkstoneDirectory {
+			// different install, different archives
+			nextView := New(msg.Config)
 			return nextView, nextView.Init()
 		}
+		m.applyConfig(msg.Config)
+		return m, nil
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
 		m.list.SetSize(msg.Width-h, msg.Height-v)
@@ -79,6 +188,138 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// the toggles start out as whatever the settings say
+func (m *model) applyConfig(conf config.Config) {
+	m.conf = conf
+	m.metadata.setConfig(conf)
+	m.replaceRaw = conf.Extraction.ReplaceRaw
+	m.enabledConverters = make(map[string]bool)
+	for _, converterName := range conf.Extraction.Converters {
+		m.enabledConverters[converterName] = true
+	}
+	m.updateConverterHelp()
+}
+
 func (m model) View() string {
 	return docStyle.Render(m.list.View())
 }
+
+func (m model) CapturingInput() bool {
+	return m.list.FilterState() == list.Filtering
+}
+
+// metadata is still read in while an archive is open, so the sort order is right on the way back
+func (m model) ReceivesInBackground(msg tea.Msg) bool {
+	_, ok := msg.(metadataLoadedMsg)
+	return ok
+}
+
+func (m model) ShortHelp() []key.Binding {
+	return m.list.ShortHelp()
+}
+
+func (m model) FullHelp() [][]key.Binding {
+	return m.list.FullHelp()
+}
+
+func converterKey(index int) string {
+	return fmt.Sprintf("%d", index+1)
+}
+
+func (m model) convertOptions() lib.ConvertOptions {
+	convertOptions := lib.ConvertOptions{Replace: m.replaceRaw, Overwrite: m.conf.Extraction.Overwrite}
+	for _, converter := range lib.Converters {
+		if m.enabledConverters[converter.Name] {
+			convertOptions.Converters = append(convertOptions.Converters, converter)
+		}
+	}
+
+	return convertOptions
+}
+
+func (m model) archivePaths() []string {
+	archivePaths := make([]string, 0, len(m.list.Items()))
+	for _, listItem := range m.list.Items() {
+		archivePaths = append(archivePaths, listItem.(item).Path)
+	}
+
+	return archivePaths
+}
+
+// in list order, not the order they were picked in
+func (m model) selectedPaths() []string {
+	selectedPaths := make([]string, 0)
+	for _, listItem := range m.list.Items() {
+		if listItem.(item).Selected {
+			selectedPaths = append(selectedPaths, listItem.(item).Path)
+		}
+	}
+
+	return selectedPaths
+}
+
+func (m *model) setSelected(path string, selected bool) tea.Cmd {
+	for i, listItem := range m.list.Items() {
+		if listItem.(item).Path == path {
+			updated := listItem.(item)
+			updated.Selected = selected
+			return m.list.SetItem(i, updated)
+		}
+	}
+
+	return nil
+}
+
+// the help line doubles as the display of which converters are on
+func (m *model) updateConverterHelp() {
+	onOff := func(enabled bool) string {
+		if enabled {
+			return "on"
+		}
+		return "off"
+	}
+
+	extractHelp := "extract everything"
+	if selectedCount := len(m.selectedPaths()); selectedCount > 0 {
+		extractHelp = fmt.Sprintf("extract %d selected", selectedCount)
+	}
+
+	bindings := make([]key.Binding, 0, len(lib.Converters)+7)
+	bindings = append(bindings, key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "select"),
+	), key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "select all"),
+	), key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", extractHelp),
+	))
+	for i, converter := range lib.Converters {
+		bindings = append(bindings, key.NewBinding(
+			key.WithKeys(converterKey(i)),
+			key.WithHelp(converterKey(i), fmt.Sprintf("%s: %s", converter.Description, onOff(m.enabledConverters[converter.Name]))),
+		))
+	}
+	bindings = append(bindings, key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "replace raw: "+onOff(m.replaceRaw)),
+	), key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o/O", "sort: "+m.sortColumn.String()),
+	), key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "find in all archives"),
+	), key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "search archive contents"),
+	), key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "settings"),
+	))
+
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return bindings
+	}
+	m.list.AdditionalFullHelpKeys = m.list.AdditionalShortHelpKeys
+}
@@ -0,0 +1,174 @@
+package archive_picker
+
+import (
+	"fmt"
+	"sort"
+	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/format"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// archives hashed at once, it is the disk that limits this rather than the cpu
+const metadataReaders = 2
+
+// filled in from the background, shared by every item so the list shows whatever has arrived,
+// even when the picker was not the screen receiving the messages at the time
+type archiveMetadata struct {
+	mutex  sync.Mutex
+	infos  map[string]lib.ArchiveInfo
+	errors map[string]error
+	slots  chan struct{}
+
+	// the retail hashes come from the settings, so they are looked up when shown rather than when read
+	conf config.Config
+}
+
+type metadataLoadedMsg struct {
+	archivePath string
+}
+
+func newArchiveMetadata() *archiveMetadata {
+	return &archiveMetadata{
+		infos:  make(map[string]lib.ArchiveInfo),
+		errors: make(map[string]error),
+		slots:  make(chan struct{}, metadataReaders),
+	}
+}
+
+func (a *archiveMetadata) get(archivePath string) (lib.ArchiveInfo, error, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	info, ok := a.infos[archivePath]
+	err, failed := a.errors[archivePath]
+	return info, err, ok || failed
+}
+
+func (a *archiveMetadata) setConfig(conf config.Config) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.conf = conf
+}
+
+func (a *archiveMetadata) retailRelease(info lib.ArchiveInfo) string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.conf.RetailRelease(info.Sha1)
+}
+
+func loadMetadata(metadata *archiveMetadata, archivePath string) tea.Cmd {
+	return func() tea.Msg {
+		metadata.slots <- struct{}{}
+		info, err := lib.ReadArchiveInfo(archivePath)
+		<-metadata.slots
+
+		metadata.mutex.Lock()
+		if err != nil {
+			metadata.errors[archivePath] = err
+		} else {
+			metadata.infos[archivePath] = info
+		}
+		metadata.mutex.Unlock()
+
+		return metadataLoadedMsg{archivePath: archivePath}
+	}
+}
+
+// the columns shown under each archive's name
+func (a *archiveMetadata) describe(archivePath string) string {
+	info, err, loaded := a.get(archivePath)
+	if !loaded {
+		return "reading…"
+	}
+	if err != nil {
+		return fmt.Sprintf("unreadable: %v", err)
+	}
+
+	retail := "sha1 " + info.Sha1[:8]
+	if release := a.retailRelease(info); release != "" {
+		retail = "retail " + release
+	}
+
+	modified := "no date        "
+	if !info.ModTime.IsZero() {
+		modified = info.ModTime.Local().Format("2006-01-02 15:04")
+	}
+
+	return fmt.Sprintf("%5d entries • %10s • %3.0f%% compressed • %s • %s",
+		info.Entries, format.Bytes(uint64(info.Size)), info.CompressedShare()*100, modified, retail)
+}
+
+type sortColumn int
+
+const (
+	sortName sortColumn = iota
+	sortEntries
+	sortSize
+	sortCompressed
+	sortModified
+	sortRetail
+)
+
+var sortColumnNames = []string{"name", "entries", "size", "compressed", "modified", "retail"}
+
+func (c sortColumn) String() string {
+	return sortColumnNames[c]
+}
+
+// names go a to z, everything else biggest or newest first, archives still loading always go last
+func (m *model) sortItems() tea.Cmd {
+	items := append([]list.Item{}, m.list.Items()...)
+
+	less := func(a, b item) bool {
+		if m.sortColumn == sortName {
+			return strings.ToLower(a.FileName) < strings.ToLower(b.FileName)
+		}
+
+		aInfo, _, _ := m.metadata.get(a.Path)
+		bInfo, _, _ := m.metadata.get(b.Path)
+		switch m.sortColumn {
+		case sortEntries:
+			return aInfo.Entries > bInfo.Entries
+		case sortSize:
+			return aInfo.Size > bInfo.Size
+		case sortCompressed:
+			return aInfo.CompressedShare() > bInfo.CompressedShare()
+		case sortModified:
+			return aInfo.ModTime.After(bInfo.ModTime)
+		case sortRetail:
+			return m.metadata.retailRelease(aInfo) > m.metadata.retailRelease(bInfo)
+		}
+		return false
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].(item), items[j].(item)
+		_, _, aLoaded := m.metadata.get(a.Path)
+		_, _, bLoaded := m.metadata.get(b.Path)
+		if m.sortColumn != sortName && aLoaded != bLoaded {
+			return aLoaded
+		}
+		if m.sortReversed {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+
+	m.list.Title = "MTF Archives"
+	if m.sortColumn != sortName || m.sortReversed {
+		direction := "↓"
+		if m.sortReversed {
+			direction = "↑"
+		}
+		m.list.Title = fmt.Sprintf("MTF Archives by %s %s", m.sortColumn, direction)
+	}
+
+	return m.list.SetItems(items)
+}
@@ -0,0 +1,45 @@
+package content_search
+
+import (
+	"context"
+	"stone-tools/lib"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type grepMatchMsg struct {
+	searchId int
+	match    lib.GrepMatch
+}
+
+type grepDoneMsg struct {
+	searchId int
+	stats    lib.GrepStats
+	err      error
+}
+
+// matches stream through sub while the search runs, the channel is closed once it is over
+func runGrep(ctx context.Context, sub chan grepMatchMsg, searchId int, archivePaths []string, options lib.GrepOptions) tea.Cmd {
+	return func() tea.Msg {
+		defer close(sub)
+
+		stats, err := lib.Grep(ctx, archivePaths, options, func(match lib.GrepMatch) {
+			select {
+			case sub <- grepMatchMsg{searchId: searchId, match: match}:
+			case <-ctx.Done():
+			}
+		})
+
+		return grepDoneMsg{searchId: searchId, stats: stats, err: err}
+	}
+}
+
+func waitForMatch(sub chan grepMatchMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-sub
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
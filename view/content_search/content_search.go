@@ -0,0 +1,408 @@
+package content_search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/archive_browser"
+	"stone-tools/view/filters"
+	"stone-tools/view/format"
+	"stone-tools/view/router"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	headerHeight = 6
+	footerHeight = 2
+
+	// past this only the count keeps going, nobody scrolls through more
+	maxResults = 5000
+
+	offsetColumnWidth = 12
+)
+
+const (
+	patternInput = iota
+	archiveInput
+	extensionInput
+	resultsFocus
+)
+
+var modes = []lib.GrepMode{lib.GrepLiteral, lib.GrepRegex, lib.GrepHex}
+
+var (
+	docStyle    = lipgloss.NewStyle().Margin(1, 2)
+	titleStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#25A065")).Padding(0, 1)
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Bold(true)
+	matchStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Underline(true)
+	columnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render
+)
+
+type model struct {
+	conf           config.Config
+	archivePaths   []string
+	convertOptions lib.ConvertOptions
+
+	inputs     []textinput.Model
+	focus      int
+	mode       int
+	ignoreCase bool
+
+	searchId   int
+	searchMode lib.GrepMode
+	cancel     context.CancelFunc
+	sub        chan grepMatchMsg
+	spinner    spinner.Model
+	searching  bool
+	stats      lib.GrepStats
+	canceled   bool
+	err        error
+
+	results    []lib.GrepMatch
+	matchCount int
+	cursor     int
+	offset     int
+}
+
+func New(conf config.Config, archivePaths []string, convertOptions lib.ConvertOptions) model {
+	pattern := textinput.New()
+	pattern.Prompt = "Search:     "
+	pattern.Placeholder = "e.g. TORCHE or de ad be ef"
+	pattern.Focus()
+
+	archives := textinput.New()
+	archives.Prompt = "Archives:   "
+	archives.Placeholder = "all, or globs like DATA*.MTF"
+
+	extensions := textinput.New()
+	extensions.Prompt = "Extensions: "
+	extensions.Placeholder = "all, or a list like txt,ini"
+
+	return model{
+		conf:           conf,
+		archivePaths:   archivePaths,
+		convertOptions: convertOptions,
+
+		inputs:  []textinput.Model{pattern, archives, extensions},
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case grepMatchMsg:
+		if msg.searchId != m.searchId {
+			return m, nil
+		}
+		m.matchCount++
+		if len(m.results) < maxResults {
+			m.results = append(m.results, msg.match)
+		}
+		return m, waitForMatch(m.sub)
+	case grepDoneMsg:
+		if msg.searchId != m.searchId {
+			return m, nil
+		}
+		m.searching = false
+		m.stats = msg.stats
+		m.canceled = errors.Is(msg.err, context.Canceled)
+		if !m.canceled {
+			m.err = msg.err
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if !m.searching {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.WindowSizeMsg:
+		m.moveCursor(0)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.searching {
+				m.stop()
+				return m, nil
+			}
+			return m, router.Back()
+		case "tab":
+			return m, m.setFocus((m.focus + 1) % (resultsFocus + 1))
+		case "shift+tab":
+			return m, m.setFocus((m.focus + resultsFocus) % (resultsFocus + 1))
+		case "ctrl+t":
+			m.mode = (m.mode + 1) % len(modes)
+			return m, nil
+		case "ctrl+y":
+			m.ignoreCase = !m.ignoreCase
+			return m, nil
+		}
+
+		if m.focus == resultsFocus {
+			return m.updateResults(msg)
+		}
+		if msg.String() == "enter" {
+			return m, m.start()
+		}
+	}
+
+	if m.focus == resultsFocus {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-m.pageHeight())
+	case "pgdown":
+		m.moveCursor(m.pageHeight())
+	case "enter":
+		if m.cursor >= len(m.results) {
+			return m, nil
+		}
+
+		match := m.results[m.cursor]
+		return m, router.Push(archive_browser.New(m.conf, match.ArchivePath, m.convertOptions).Reveal(match.FileName))
+	}
+
+	return m, nil
+}
+
+func (m *model) setFocus(focus int) tea.Cmd {
+	m.focus = focus
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
+	if focus == resultsFocus {
+		return nil
+	}
+
+	return m.inputs[focus].Focus()
+}
+
+// a new search replaces whatever was running, late messages from the old one are told apart by searchId
+func (m *model) start() tea.Cmd {
+	m.stop()
+
+	m.searchId++
+	m.results = nil
+	m.matchCount = 0
+	m.cursor = 0
+	m.offset = 0
+	m.stats = lib.GrepStats{}
+	m.canceled = false
+	m.err = nil
+
+	options := lib.GrepOptions{
+		Mode:           modes[m.mode],
+		Pattern:        m.inputs[patternInput].Value(),
+		IgnoreCase:     m.ignoreCase,
+		ArchiveFilters: splitList(m.inputs[archiveInput].Value()),
+		Extensions:     splitList(m.inputs[extensionInput].Value()),
+		Workers:        m.conf.Extraction.Workers,
+	}
+	if strings.TrimSpace(options.Pattern) == "" {
+		return nil
+	}
+	m.searchMode = options.Mode
+
+	var ctx context.Context
+	ctx, m.cancel = context.WithCancel(context.Background())
+	m.sub = make(chan grepMatchMsg)
+	m.searching = true
+
+	return tea.Batch(
+		runGrep(ctx, m.sub, m.searchId, m.archivePaths, options),
+		waitForMatch(m.sub),
+		m.spinner.Tick,
+	)
+}
+
+func (m *model) stop() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+func (m *model) moveCursor(delta int) {
+	m.cursor = max(0, min(len(m.results)-1, m.cursor+delta))
+
+	pageHeight := m.pageHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+pageHeight {
+		m.offset = m.cursor - pageHeight + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.results)-pageHeight))
+}
+
+func (m model) pageHeight() int {
+	_, v := docStyle.GetFrameSize()
+	return max(1, filters.GlobalWindowSize.Height-v-headerHeight-footerHeight)
+}
+
+func (m model) CapturingInput() bool {
+	return m.focus != resultsFocus
+}
+
+// a search keeps running while a match is open in the browser
+func (m model) ReceivesInBackground(msg tea.Msg) bool {
+	switch msg.(type) {
+	case grepMatchMsg, grepDoneMsg, spinner.TickMsg:
+		return true
+	}
+	return false
+}
+
+func (m model) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Search Archive Contents") + "\n")
+
+	for _, input := range m.inputs {
+		s.WriteString(input.View() + "\n")
+	}
+
+	caseText := "match case"
+	if m.ignoreCase {
+		caseText = "ignore case"
+	}
+	s.WriteString(helpStyle(fmt.Sprintf("mode: %s (ctrl+t) • %s (ctrl+y)", modes[m.mode], caseText)) + "\n")
+	s.WriteString(m.status() + "\n")
+
+	h, _ := docStyle.GetFrameSize()
+	width := max(40, filters.GlobalWindowSize.Width-h)
+	nameWidth := max(16, width/3)
+	snippetWidth := max(10, width-nameWidth-offsetColumnWidth-2)
+	s.WriteString(columnStyle.Render(pad("File", nameWidth)+"  "+pad("Offset", offsetColumnWidth)+"Match") + "\n")
+
+	pageHeight := m.pageHeight()
+	for i := m.offset; i < min(len(m.results), m.offset+pageHeight); i++ {
+		match := m.results[i]
+		name := truncateStart(archiveName(match.ArchivePath)+lib.IsoPathSeparator+filepath.ToSlash(match.FileName), nameWidth)
+		line := pad(name, nameWidth) + "  " + pad(fmt.Sprintf("0x%x", match.Offset), offsetColumnWidth)
+		if i == m.cursor && m.focus == resultsFocus {
+			line = cursorStyle.Render(line)
+		}
+		s.WriteString(line + m.snippet(match, snippetWidth) + "\n")
+	}
+	for i := len(m.results) - m.offset; i < pageHeight; i++ {
+		s.WriteString("\n")
+	}
+
+	s.WriteString(helpStyle("enter search • tab next field/results • ↑/↓ move • enter open in archive • esc stop/back"))
+	return docStyle.Render(s.String())
+}
+
+func (m model) status() string {
+	summary := fmt.Sprintf("%d matches in %d entries (%s) across %d archives", m.matchCount, m.stats.Entries, format.Bytes(uint64(m.stats.Bytes)), m.stats.Archives)
+	if m.matchCount > len(m.results) {
+		summary += fmt.Sprintf(", showing the first %d", len(m.results))
+	}
+
+	switch {
+	case m.searching:
+		return m.spinner.View() + fmt.Sprintf(" Searching... %d matches so far", m.matchCount)
+	case m.err != nil:
+		return errorStyle(fmt.Sprintf("Error: %v", m.err))
+	case m.searchId == 0:
+		return "Type a pattern and press enter."
+	case m.canceled:
+		summary += " • canceled"
+	}
+	if len(m.stats.Errors) > 0 {
+		summary += errorStyle(fmt.Sprintf(" • %d entries could not be read", len(m.stats.Errors)))
+	}
+
+	return summary
+}
+
+// the match is kept in view and centred when the context does not all fit
+func (m model) snippet(match lib.GrepMatch, width int) string {
+	before, matched, after := match.SnippetText()
+	if m.searchMode == lib.GrepHex {
+		before, matched, after = match.SnippetHex()
+		// the bytes either side of the match still need their separator
+		if before != "" {
+			before += " "
+		}
+		if after != "" {
+			after = " " + after
+		}
+	}
+
+	matchRunes := []rune(matched)
+	if len(matchRunes) > width {
+		return matchStyle.Render(string(matchRunes[:width-1]) + "…")
+	}
+
+	side := (width - len(matchRunes)) / 2
+	beforeRunes, afterRunes := []rune(before), []rune(after)
+	if len(beforeRunes) > side {
+		beforeRunes = beforeRunes[len(beforeRunes)-side:]
+	}
+	if len(afterRunes) > width-len(matchRunes)-len(beforeRunes) {
+		afterRunes = afterRunes[:width-len(matchRunes)-len(beforeRunes)]
+	}
+
+	return string(beforeRunes) + matchStyle.Render(matched) + string(afterRunes)
+}
+
+// archives inside an iso keep the image name so the same archive on two discs can be told apart
+func archiveName(archivePath string) string {
+	if isoPath, innerPath, inIso := lib.SplitArchivePath(archivePath); inIso {
+		return filepath.Base(isoPath) + lib.IsoPathSeparator + filepath.Base(innerPath)
+	}
+
+	return filepath.Base(archivePath)
+}
+
+// keep the end, that is the part that tells files apart
+func truncateStart(s string, width int) string {
+	if runes := []rune(s); len(runes) > width {
+		return "…" + string(runes[len(runes)-width+1:])
+	}
+
+	return s
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-lipgloss.Width(s)))
+}
+
+func splitList(value string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
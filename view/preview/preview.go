@@ -0,0 +1,312 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"path/filepath"
+	"stone-tools/lib"
+	"stone-tools/lib/tga"
+	"stone-tools/view/format"
+	"strings"
+	"unicode/utf8"
+)
+
+type kind int
+
+const (
+	kindHex kind = iota
+	kindText
+	kindImage
+	kindModel
+)
+
+// how many bytes are looked at when guessing whether an unknown file is text
+const textSniffLength = 512
+
+// the decoded form of a virtual file, parsed once and rendered at whatever size the pane currently is
+type Content struct {
+	fileName string
+	data     []byte
+	kind     kind
+
+	text   string
+	image  image.Image
+	model  lib.O3DModel
+	issues []lib.O3DIssue
+
+	// set when the file claims to be an image or model but would not parse, it falls back to hex
+	err error
+}
+
+func New(fileName string, data []byte) Content {
+	c := Content{fileName: fileName, data: data, kind: kindHex}
+
+	switch extension := strings.ToLower(filepath.Ext(fileName)); {
+	case extension == ".tga":
+		c.image, c.err = tga.Decode(bytes.NewReader(data))
+		if c.err == nil {
+			c.kind = kindImage
+		}
+	case extension == ".o3d":
+		c.model, c.err = lib.ExtractO3D(bytes.NewReader(data))
+		if c.err == nil {
+			c.kind = kindModel
+			c.issues = lib.ValidateO3D(c.model, nil)
+		}
+	case isTextExtension(extension) || looksLikeText(data):
+		c.kind = kindText
+		c.text = lib.DecodeCP1252(data)
+		if utf8.Valid(data) {
+			c.text = string(data)
+		}
+	}
+
+	return c
+}
+
+func (c Content) Render(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+
+	var lines []string
+	switch c.kind {
+	case kindText:
+		lines = c.renderText(width, height)
+	case kindImage:
+		lines = c.renderImage(width, height)
+	case kindModel:
+		lines = c.renderModel(width, height)
+	default:
+		lines = c.renderHex(width, height)
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isTextExtension(extension string) bool {
+	for _, textExtension := range lib.TextToUtf8Converter.Extensions {
+		if extension == textExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+// no nul bytes and mostly printable, control characters other than whitespace give binaries away quickly
+func looksLikeText(data []byte) bool {
+	sniff := data[:min(len(data), textSniffLength)]
+	if len(sniff) == 0 {
+		return false
+	}
+
+	printable := 0
+	for _, b := range sniff {
+		switch {
+		case b == 0:
+			return false
+		case b >= 0x20 || b == '\n' || b == '\r' || b == '\t':
+			printable++
+		}
+	}
+
+	return printable*100/len(sniff) >= 95
+}
+
+func (c Content) renderText(width, height int) []string {
+	lines := make([]string, 0, height)
+	for _, line := range strings.Split(strings.ReplaceAll(c.text, "\r\n", "\n"), "\n") {
+		if len(lines) == height {
+			break
+		}
+
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if runes := []rune(line); len(runes) > width {
+			line = string(runes[:width-1]) + "…"
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func (c Content) renderHex(width, height int) []string {
+	lines := make([]string, 0, height)
+	if c.err != nil {
+		lines = append(lines, truncate(fmt.Sprintf("could not parse: %v", c.err), width))
+	}
+
+	// 8 offset digits and 2 spaces, then 3 columns per byte in hex plus 1 in the ascii column
+	bytesPerLine := max(4, min(16, (width-10-1)/4/4*4))
+	for offset := 0; offset < len(c.data); offset += bytesPerLine {
+		if len(lines) == height-1 && offset+bytesPerLine < len(c.data) {
+			lines = append(lines, fmt.Sprintf("… %s more", format.Bytes(uint64(len(c.data)-offset))))
+			break
+		}
+
+		chunk := c.data[offset:min(len(c.data), offset+bytesPerLine)]
+
+		var hexColumn, asciiColumn strings.Builder
+		for i := range bytesPerLine {
+			if i >= len(chunk) {
+				hexColumn.WriteString("   ")
+				continue
+			}
+
+			fmt.Fprintf(&hexColumn, "%02x ", chunk[i])
+			if chunk[i] >= 0x20 && chunk[i] < 0x7f {
+				asciiColumn.WriteByte(chunk[i])
+			} else {
+				asciiColumn.WriteByte('.')
+			}
+		}
+
+		lines = append(lines, fmt.Sprintf("%08x  %s%s", offset, hexColumn.String(), asciiColumn.String()))
+	}
+
+	if len(c.data) == 0 {
+		lines = append(lines, "(empty file)")
+	}
+
+	return lines
+}
+
+// two pixels per cell using the upper half block, foreground on top and background below
+func (c Content) renderImage(width, height int) []string {
+	bounds := c.image.Bounds()
+	lines := []string{truncate(fmt.Sprintf("%dx%d texture", bounds.Dx(), bounds.Dy()), width)}
+	if bounds.Empty() || height < 2 {
+		return lines
+	}
+
+	scale := min(float64(width)/float64(bounds.Dx()), float64((height-1)*2)/float64(bounds.Dy()))
+	columns := max(1, int(float64(bounds.Dx())*scale))
+	pixelRows := max(2, int(float64(bounds.Dy())*scale))
+
+	pixel := func(x, y int) color.NRGBA {
+		sourceX := bounds.Min.X + min(bounds.Dx()-1, int(float64(x)/scale))
+		sourceY := bounds.Min.Y + min(bounds.Dy()-1, int(float64(y)/scale))
+		return flatten(color.NRGBAModel.Convert(c.image.At(sourceX, sourceY)).(color.NRGBA))
+	}
+
+	for y := 0; y < pixelRows; y += 2 {
+		var line strings.Builder
+		for x := range columns {
+			top := pixel(x, y)
+			bottom := top
+			if y+1 < pixelRows {
+				bottom = pixel(x, y+1)
+			}
+			fmt.Fprintf(&line, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+		line.WriteString("\x1b[0m")
+		lines = append(lines, line.String())
+	}
+
+	return lines
+}
+
+// blends transparency over a dark grey so it reads like the contact sheets
+func flatten(c color.NRGBA) color.NRGBA {
+	const background = 32
+	blend := func(channel uint8) uint8 {
+		return uint8((int(channel)*int(c.A) + background*(255-int(c.A))) / 255)
+	}
+
+	return color.NRGBA{R: blend(c.R), G: blend(c.G), B: blend(c.B), A: 255}
+}
+
+func (c Content) renderModel(width, height int) []string {
+	triangles, quads := 0, 0
+	for _, face := range c.model.Faces {
+		if face.V3 == lib.O3DUnused {
+			triangles++
+		} else {
+			quads++
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Vertices:  %d", len(c.model.Vertices)),
+		fmt.Sprintf("Faces:     %d (%d triangles, %d quads)", len(c.model.Faces), triangles, quads),
+	}
+
+	if len(c.model.Vertices) > 0 {
+		minimum := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+		maximum := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+		for _, vertex := range c.model.Vertices {
+			for axis, value := range [3]float32{vertex.X, vertex.Y, vertex.Z} {
+				minimum[axis] = min(minimum[axis], float64(value))
+				maximum[axis] = max(maximum[axis], float64(value))
+			}
+		}
+		lines = append(lines,
+			fmt.Sprintf("Min:       %.2f, %.2f, %.2f", minimum[0], minimum[1], minimum[2]),
+			fmt.Sprintf("Max:       %.2f, %.2f, %.2f", maximum[0], maximum[1], maximum[2]),
+		)
+	}
+
+	materialIds := lib.O3DMaterialIds(c.model)
+	materialNames := make([]string, len(materialIds))
+	for i, materialId := range materialIds {
+		materialNames[i] = lib.ObjMaterialName(materialId)
+	}
+	lines = append(lines, fmt.Sprintf("Materials: %d", len(materialIds)))
+	lines = append(lines, wrap(strings.Join(materialNames, " "), width, "  ")...)
+
+	errors, warnings := 0, 0
+	for _, issue := range c.issues {
+		switch issue.Severity {
+		case lib.O3DSeverityError:
+			errors++
+		case lib.O3DSeverityWarning:
+			warnings++
+		}
+	}
+	lines = append(lines, "", fmt.Sprintf("Issues:    %d errors, %d warnings", errors, warnings))
+	for _, issue := range c.issues {
+		if issue.Severity != lib.O3DSeverityInfo {
+			lines = append(lines, truncate("  "+issue.Message, width))
+		}
+	}
+
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	return lines
+}
+
+func truncate(s string, width int) string {
+	if runes := []rune(s); len(runes) > width {
+		return string(runes[:max(0, width-1)]) + "…"
+	}
+
+	return s
+}
+
+func wrap(s string, width int, indent string) []string {
+	lines := make([]string, 0)
+	line := indent
+	for _, word := range strings.Fields(s) {
+		if len(line)+len(word) > width && line != indent {
+			lines = append(lines, line)
+			line = indent
+		}
+		if line != indent {
+			line += " "
+		}
+		line += word
+	}
+	if line != indent {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
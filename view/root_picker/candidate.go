@@ -0,0 +1,161 @@
+package root_picker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"stone-tools/lib"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// how far below the candidate to look for an install, above it goes all the way up
+const suggestionDepth = 2
+
+// what the folder about to be picked holds, filled in from the background
+type candidate struct {
+	directory     string
+	scanned       bool
+	archives      int
+	size          int64
+	hasData       bool
+	hasExecutable bool
+	suggestion    string // closest directory that does look like an install, empty when this one does or none is near
+}
+
+func (c candidate) looksLikeInstall() bool {
+	return c.hasData && c.hasExecutable
+}
+
+type candidateScannedMsg struct {
+	candidate candidate
+}
+
+// starts over for the picker's current directory, leaving whatever was scanning before behind
+func (m *model) rescan() tea.Cmd {
+	m.cancelScan()
+	m.scanCtx, m.cancelScan = context.WithCancel(context.Background())
+	m.candidate = candidate{directory: m.conf.DarkstoneDirectory}
+
+	return scan(m.scanCtx, m.conf.DarkstoneDirectory)
+}
+
+func scan(ctx context.Context, directory string) tea.Cmd {
+	return func() tea.Msg {
+		scanned, err := scanDirectory(ctx, directory)
+		if err != nil {
+			// canceled, a newer scan has taken over
+			return nil
+		}
+		return candidateScannedMsg{candidate: scanned}
+	}
+}
+
+func scanDirectory(ctx context.Context, directory string) (candidate, error) {
+	c := candidate{directory: directory, scanned: true}
+	c.hasData, c.hasExecutable = installMarkers(directory)
+
+	// unreadable folders along the way only mean fewer archives, a cancel means stop
+	archivePaths, _ := lib.FindArchivesContext(ctx, directory)
+	if ctx.Err() != nil {
+		return c, ctx.Err()
+	}
+
+	for _, archivePath := range archivePaths {
+		mtfFile, closer, err := lib.OpenArchiveFile(archivePath)
+		if err != nil {
+			continue
+		}
+		c.archives++
+		c.size += mtfFile.Size()
+		closer.Close()
+	}
+
+	if !c.looksLikeInstall() {
+		c.suggestion = nearestInstall(ctx, directory)
+	}
+
+	return c, ctx.Err()
+}
+
+// a darkstone install has its main archive and the game's executable side by side
+func installMarkers(directory string) (bool, bool) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return false, false
+	}
+
+	hasData, hasExecutable := false, false
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := strings.ToLower(entry.Name())
+		if name == "data.mtf" {
+			hasData = true
+		}
+		if filepath.Ext(name) == ".exe" && strings.Contains(name, "darkstone") {
+			hasExecutable = true
+		}
+	}
+
+	return hasData, hasExecutable
+}
+
+func looksLikeInstall(directory string) bool {
+	hasData, hasExecutable := installMarkers(directory)
+	return hasData && hasExecutable
+}
+
+// children and parents one step away are checked before anything two steps away and so on,
+// children first when both are as close
+func nearestInstall(ctx context.Context, directory string) string {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return ""
+	}
+
+	level := []string{directory}
+	parent := directory
+	for distance := 1; ctx.Err() == nil; distance++ {
+		if distance <= suggestionDepth {
+			level = childDirectories(level)
+			for _, child := range level {
+				if looksLikeInstall(child) {
+					return child
+				}
+			}
+		}
+
+		next := filepath.Dir(parent)
+		if next == parent {
+			return ""
+		}
+		parent = next
+		if looksLikeInstall(parent) {
+			return parent
+		}
+	}
+
+	return ""
+}
+
+// hidden folders are left out, an install does not live in one and they can be large
+func childDirectories(directories []string) []string {
+	children := make([]string, 0)
+	for _, directory := range directories {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				children = append(children, filepath.Join(directory, entry.Name()))
+			}
+		}
+	}
+
+	return children
+}
@@ -1,49 +1,118 @@
 package root_picker
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"stone-tools/config"
+	"stone-tools/install"
 	"stone-tools/view/archive_picker"
+	"stone-tools/view/filters"
+	"stone-tools/view/format"
+	"stone-tools/view/router"
 
 	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+var (
+	docStyle     = lipgloss.NewStyle().Margin(1, 2)
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+)
+
+type installItem struct {
+	install install.Install
+}
+
+func (i installItem) Title() string       { return i.install.Path }
+func (i installItem) Description() string { return "- found through " + i.install.Source }
+func (i installItem) FilterValue() string { return i.install.Path }
+
+type browseItem struct{}
+
+func (i browseItem) Title() string       { return "Browse..." }
+func (i browseItem) Description() string { return "- navigate to the installation by hand" }
+func (i browseItem) FilterValue() string { return "browse" }
+
 type model struct {
 	filepicker filepicker.Model
 	conf       config.Config
-	quitting   bool
 	err        error
+
+	// several detected installs are offered as a list before falling back to browsing
+	choosing bool
+	choices  list.Model
+
+	// the folder being browsed is checked in the background before `c` takes it
+	candidate  candidate
+	scanCtx    context.Context
+	cancelScan context.CancelFunc
 }
 
-func New(conf config.Config) model {
+func New(conf config.Config, installs []install.Install) model {
 	fp := filepicker.New()
 	fp.DirAllowed = true
 	fp.FileAllowed = false
 	fp.CurrentDirectory = conf.DarkstoneDirectory
 
+	choiceItems := make([]list.Item, 0, len(installs)+1)
+	for _, detected := range installs {
+		choiceItems = append(choiceItems, installItem{install: detected})
+	}
+	choiceItems = append(choiceItems, browseItem{})
+
+	choices := list.New(choiceItems, list.NewDefaultDelegate(), 0, 0)
+	choices.Title = "Darkstone Installations"
+	// q quits through the router, esc goes back the same way
+	choices.KeyMap.Quit.SetEnabled(false)
+	h, v := docStyle.GetFrameSize()
+	choices.SetSize(filters.GlobalWindowSize.Width-h, filters.GlobalWindowSize.Height-v)
+
+	scanCtx, cancelScan := context.WithCancel(context.Background())
 	return model{
 		filepicker: fp,
 		conf:       conf,
+
+		choosing: len(installs) > 1,
+		choices:  choices,
+
+		candidate:  candidate{directory: conf.DarkstoneDirectory},
+		scanCtx:    scanCtx,
+		cancelScan: cancelScan,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return m.filepicker.Init()
+	return tea.Batch(m.filepicker.Init(), scan(m.scanCtx, m.conf.DarkstoneDirectory))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(candidateScannedMsg); ok {
+		if msg.candidate.directory == m.candidate.directory {
+			m.candidate = msg.candidate
+		}
+		return m, nil
+	}
+
+	if m.choosing {
+		return m.updateChoices(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q":
-			m.quitting = true
-			return m, tea.Quit
 		case "c":
-			if m.err == nil && m.conf.DarkstoneDirectory != "" {
-				config.SaveConfig(m.conf)
-				return archive_picker.New(m.conf), nil
+			// a folder without archives would leave nothing to pick from
+			if m.err == nil && m.conf.DarkstoneDirectory != "" && m.candidate.archives > 0 {
+				return m.choose(m.conf.DarkstoneDirectory)
+			}
+		case "u":
+			if m.candidate.suggestion != "" {
+				return m.choose(m.candidate.suggestion)
 			}
 		}
 	}
@@ -57,12 +126,85 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.conf.DarkstoneDirectory = m.filepicker.CurrentDirectory
 	}
 
+	if m.conf.DarkstoneDirectory != m.candidate.directory {
+		cmd = tea.Batch(cmd, m.rescan())
+	}
+
 	return m, cmd
 }
 
+func (m model) updateChoices(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, router.Keys.Back) && m.choices.FilterState() == list.Unfiltered {
+			return m, router.Back()
+		}
+		if m.choices.FilterState() != list.Filtering {
+			switch msg.String() {
+			case "enter":
+				switch item := m.choices.SelectedItem().(type) {
+				case installItem:
+					return m.choose(item.install.Path)
+				case browseItem:
+					m.choosing = false
+					return m, nil
+				}
+			}
+		}
+
+		var cmd tea.Cmd
+		m.choices, cmd = m.choices.Update(msg)
+		return m, cmd
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.choices.SetSize(msg.Width-h, msg.Height-v)
+	}
+
+	// everything else, like the directory listing loading in, still matters to both
+	var choicesCmd, filepickerCmd tea.Cmd
+	m.choices, choicesCmd = m.choices.Update(msg)
+	m.filepicker, filepickerCmd = m.filepicker.Update(msg)
+	return m, tea.Batch(choicesCmd, filepickerCmd)
+}
+
+func (m model) choose(directory string) (tea.Model, tea.Cmd) {
+	m.cancelScan()
+	m.conf.DarkstoneDirectory = directory
+	config.SaveConfig(m.conf)
+	return m, router.Push(archive_picker.New(m.conf))
+}
+
+func (m model) CapturingInput() bool {
+	return m.choosing && m.choices.FilterState() == list.Filtering
+}
+
+var (
+	chooseKey     = key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "use this folder"))
+	suggestionKey = key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "use the suggested folder"))
+)
+
+func (m model) ShortHelp() []key.Binding {
+	if m.choosing {
+		return m.choices.ShortHelp()
+	}
+	return []key.Binding{m.filepicker.KeyMap.Open, m.filepicker.KeyMap.Back, chooseKey, suggestionKey}
+}
+
+func (m model) FullHelp() [][]key.Binding {
+	if m.choosing {
+		return m.choices.FullHelp()
+	}
+
+	keyMap := m.filepicker.KeyMap
+	return [][]key.Binding{
+		{keyMap.Up, keyMap.Down, keyMap.PageUp, keyMap.PageDown, keyMap.GoToTop, keyMap.GoToLast},
+		{keyMap.Open, keyMap.Back, chooseKey, suggestionKey},
+	}
+}
+
 func (m model) View() string {
-	if m.quitting {
-		return ""
+	if m.choosing {
+		return docStyle.Render(m.choices.View())
 	}
 	var s strings.Builder
 	s.WriteString("\n  ")
@@ -73,8 +215,32 @@ func (m model) View() string {
 		s.WriteString("\n")
 	} else {
 		s.WriteString("Current folder: " + m.filepicker.Styles.Selected.Render(m.conf.DarkstoneDirectory))
-		s.WriteString("\n  - Hit `c` to select as root.")
+		s.WriteString(m.candidateView())
 	}
 	s.WriteString("\n\n" + m.filepicker.View() + "\n")
 	return s.String()
 }
+
+func (m model) candidateView() string {
+	if !m.candidate.scanned {
+		return "\n  - Checking folder…"
+	}
+
+	var s strings.Builder
+	c := m.candidate
+	s.WriteString(fmt.Sprintf("\n  - %d archives, %s", c.archives, format.Bytes(uint64(c.size))))
+	if !c.hasData {
+		s.WriteString("\n  - " + warningStyle.Render("No DATA.MTF here, this does not look like a Darkstone install."))
+	}
+	if !c.hasExecutable {
+		s.WriteString("\n  - " + warningStyle.Render("No Darkstone executable here."))
+	}
+	if c.suggestion != "" {
+		s.WriteString("\n  - Looks like an install: " + m.filepicker.Styles.Selected.Render(c.suggestion) + ", hit `u` to use it instead.")
+	}
+	if c.archives > 0 {
+		s.WriteString("\n  - Hit `c` to select as root.")
+	}
+
+	return s.String()
+}
@@ -0,0 +1,192 @@
+package router
+
+import (
+	"stone-tools/view/filters"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the keys every screen shares, screens check Back themselves since esc often means something local first.
+// q only quits while the screen is not taking typed input
+type KeyMap struct {
+	Back key.Binding
+	Help key.Binding
+	Quit key.Binding
+}
+
+var Keys = KeyMap{
+	Back: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+	Help: key.NewBinding(key.WithKeys("?", "f1"), key.WithHelp("?/f1", "toggle help")),
+	Quit: key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q/ctrl+c", "quit")),
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Back, k.Help, k.Quit}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// screens with a focused text input implement this so typing a ? does not open the help
+type InputCapturer interface {
+	CapturingInput() bool
+}
+
+// screens that keep working while another is open over them implement this to still get their own messages.
+// everything else only reaches the top screen, bubbles components tag few of their messages and would
+// otherwise pick up ones meant for the same kind of component on another screen
+type BackgroundReceiver interface {
+	ReceivesInBackground(msg tea.Msg) bool
+}
+
+type pushMsg struct {
+	view tea.Model
+}
+
+type backMsg struct {
+	then tea.Msg
+}
+
+// opens view on top of the current screen
+func Push(view tea.Model) tea.Cmd {
+	return func() tea.Msg {
+		return pushMsg{view: view}
+	}
+}
+
+// closes the current screen, quitting when it was the last one
+func Back() tea.Cmd {
+	return BackWith(nil)
+}
+
+// closes the current screen and hands msg to the one below, e.g. to say what changed
+func BackWith(msg tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return backMsg{then: msg}
+	}
+}
+
+var (
+	helpBoxStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#25A065")).Padding(1, 2)
+	helpTitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#25A065")).Padding(0, 1)
+)
+
+// a stack of screens, messages go to the top one. the screens below only see window size changes
+// and the messages they ask for as a BackgroundReceiver, so work they started keeps going
+type model struct {
+	stack    []tea.Model
+	help     help.Model
+	showHelp bool
+}
+
+func New(root tea.Model) model {
+	return model{stack: []tea.Model{root}, help: help.New()}
+}
+
+func (m model) Init() tea.Cmd {
+	return m.top().Init()
+}
+
+func (m model) top() tea.Model {
+	return m.stack[len(m.stack)-1]
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pushMsg:
+		m.stack = append(m.stack, msg.view)
+		m.showHelp = false
+		return m, msg.view.Init()
+	case backMsg:
+		m.showHelp = false
+		if len(m.stack) == 1 {
+			return m, tea.Quit
+		}
+		m.stack = m.stack[:len(m.stack)-1]
+		if msg.then == nil {
+			return m, nil
+		}
+		return m.dispatch(msg.then)
+	case tea.KeyMsg:
+		if key.Matches(msg, Keys.Quit) && !m.capturingInput(msg) {
+			return m, tea.Quit
+		}
+		if m.showHelp {
+			// any key closes the help rather than reaching the screen behind it
+			m.showHelp = false
+			return m, nil
+		}
+		if key.Matches(msg, Keys.Help) && !m.capturingInput(msg) {
+			m.showHelp = true
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.stack[len(m.stack)-1], cmd = m.top().Update(msg)
+		return m, cmd
+	case tea.WindowSizeMsg:
+		m.help.Width = msg.Width
+	}
+
+	return m.dispatch(msg)
+}
+
+func (m model) dispatch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	_, resized := msg.(tea.WindowSizeMsg)
+
+	// a fresh slice so the old model value does not share the one being changed
+	stack := make([]tea.Model, len(m.stack))
+	cmds := make([]tea.Cmd, len(m.stack))
+	for i, view := range m.stack {
+		stack[i] = view
+		if i == len(m.stack)-1 || resized || receivesInBackground(view, msg) {
+			stack[i], cmds[i] = view.Update(msg)
+		}
+	}
+	m.stack = stack
+
+	return m, tea.Batch(cmds...)
+}
+
+func receivesInBackground(view tea.Model, msg tea.Msg) bool {
+	receiver, ok := view.(BackgroundReceiver)
+	return ok && receiver.ReceivesInBackground(msg)
+}
+
+// only typed characters count, f1 still opens the help and ctrl+c still quits from a text box
+func (m model) capturingInput(msg tea.KeyMsg) bool {
+	capturer, ok := m.top().(InputCapturer)
+	return ok && msg.Type == tea.KeyRunes && capturer.CapturingInput()
+}
+
+func (m model) View() string {
+	if !m.showHelp {
+		return m.top().View()
+	}
+
+	groups := Keys.FullHelp()
+	if keyMap, ok := m.top().(help.KeyMap); ok {
+		groups = append(keyMap.FullHelp(), groups...)
+	}
+
+	// one column at a time, FullHelpView drops separators after columns with fewer keys than there are columns
+	separator := m.help.Styles.FullSeparator.Render(m.help.FullSeparator)
+	var columns []string
+	for _, group := range groups {
+		column := m.help.FullHelpView([][]key.Binding{group})
+		if column == "" {
+			continue
+		}
+		if len(columns) > 0 {
+			columns = append(columns, separator)
+		}
+		columns = append(columns, column)
+	}
+
+	box := helpBoxStyle.Render(helpTitleStyle.Render("Keys") + "\n\n" + lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+	return lipgloss.Place(filters.GlobalWindowSize.Width, filters.GlobalWindowSize.Height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// records the keys and other messages it is given, typing makes it capture input
+type testScreen struct {
+	typing     bool
+	keys       []string
+	msgs       []string
+	background []string // message types it still wants while covered
+}
+
+func (s testScreen) Init() tea.Cmd { return nil }
+func (s testScreen) View() string  { return "" }
+
+func (s testScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		s.keys = append(s.keys, msg.String())
+	default:
+		s.msgs = append(s.msgs, fmt.Sprintf("%T", msg))
+	}
+	return s, nil
+}
+
+func (s testScreen) ReceivesInBackground(msg tea.Msg) bool {
+	return slices.Contains(s.background, fmt.Sprintf("%T", msg))
+}
+
+func (s testScreen) CapturingInput() bool {
+	return s.typing
+}
+
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestQuitKeys(t *testing.T) {
+	q := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}}
+	ctrlC := tea.KeyMsg{Type: tea.KeyCtrlC}
+
+	tests := []struct {
+		name     string
+		typing   bool
+		msg      tea.KeyMsg
+		wantQuit bool
+	}{
+		{"q", false, q, true},
+		{"ctrl+c", false, ctrlC, true},
+		{"q while typing", true, q, false},
+		{"ctrl+c while typing", true, ctrlC, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := New(testScreen{typing: test.typing})
+			updated, cmd := m.Update(test.msg)
+			if isQuit(cmd) != test.wantQuit {
+				t.Fatalf("quit = %v, want %v", !test.wantQuit, test.wantQuit)
+			}
+
+			screen := updated.(model).top().(testScreen)
+			if !test.wantQuit && (len(screen.keys) != 1 || screen.keys[0] != test.msg.String()) {
+				t.Errorf("screen got %v, want the typed %s", screen.keys, test.msg)
+			}
+		})
+	}
+}
+
+func TestQuitFromHelp(t *testing.T) {
+	m := New(testScreen{})
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'?'}})
+	if !updated.(model).showHelp {
+		t.Fatal("? did not open the help")
+	}
+
+	_, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if !isQuit(cmd) {
+		t.Error("q did not quit from the help")
+	}
+}
+
+type workDoneMsg struct{}
+
+func TestMessagesReachTheTopScreen(t *testing.T) {
+	var m tea.Model = New(testScreen{})
+	m, _ = m.Update(pushMsg{view: testScreen{background: []string{"router.workDoneMsg"}}})
+	m, _ = m.Update(pushMsg{view: testScreen{}})
+
+	// list.FilterMatchesMsg carries no id, a list below the top would take the top list's matches as its own
+	for _, msg := range []tea.Msg{list.FilterMatchesMsg(nil), workDoneMsg{}, tea.WindowSizeMsg{Width: 80, Height: 24}} {
+		m, _ = m.Update(msg)
+	}
+
+	want := [][]string{
+		{"tea.WindowSizeMsg"},
+		{"router.workDoneMsg", "tea.WindowSizeMsg"},
+		{"list.FilterMatchesMsg", "router.workDoneMsg", "tea.WindowSizeMsg"},
+	}
+	for i, view := range m.(model).stack {
+		if got := view.(testScreen).msgs; !slices.Equal(got, want[i]) {
+			t.Errorf("screen %d got %v, want %v", i, got, want[i])
+		}
+	}
+
+	// what BackWith hands over is for the screen that is now on top
+	m, _ = m.Update(backMsg{then: list.FilterMatchesMsg(nil)})
+	stack := m.(model).stack
+	if got := stack[1].(testScreen).msgs; got[len(got)-1] != "list.FilterMatchesMsg" {
+		t.Errorf("the new top screen got %v, want the message handed back", got)
+	}
+	if got := stack[0].(testScreen).msgs; len(got) != 1 {
+		t.Errorf("the bottom screen got %v, want only the window size", got)
+	}
+}
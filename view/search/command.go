@@ -0,0 +1,67 @@
+package search
+
+import (
+	"stone-tools/lib"
+	"sync"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	// the router hands these messages to every open screen, so each run is numbered and a screen only
+	// listens to its own. across screens since one that was closed can still be indexing
+	lastRunId atomic.Int64
+
+	// runs share one cache file, each loads, refreshes and saves it in turn so none overwrites another's archives
+	indexMutex sync.Mutex
+)
+
+type indexProgressMsg struct {
+	runId int64
+	done  int
+	total int
+}
+
+type indexReadyMsg struct {
+	runId    int64
+	files    []lib.IndexedFile
+	failures map[string]error
+	err      error // only for the cache itself, the index still works without it
+}
+
+// brings the cached index up to date with the archives, only new or changed ones get read
+func buildIndex(sub chan indexProgressMsg, runId int64, archivePaths []string) tea.Cmd {
+	return func() tea.Msg {
+		defer close(sub)
+
+		indexMutex.Lock()
+		defer indexMutex.Unlock()
+
+		indexPath, err := lib.ArchiveIndexPath()
+		index := lib.LoadArchiveIndex(indexPath)
+		changed, failures := index.Refresh(archivePaths, func(done, total int) {
+			select {
+			case sub <- indexProgressMsg{runId: runId, done: done, total: total}:
+			default:
+				// the screen only needs the latest count, never hold indexing up for it
+			}
+		})
+
+		if changed && err == nil {
+			err = index.Save(indexPath)
+		}
+
+		return indexReadyMsg{runId: runId, files: index.Files(archivePaths), failures: failures, err: err}
+	}
+}
+
+func waitForProgress(sub chan indexProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-sub
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
@@ -0,0 +1,306 @@
+package search
+
+import (
+	"fmt"
+	"path/filepath"
+	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/archive_browser"
+	"stone-tools/view/filters"
+	"stone-tools/view/format"
+	"stone-tools/view/router"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	headerHeight = 4
+	footerHeight = 2
+
+	archiveColumnWidth = 24
+	sizeColumnWidth    = 10
+	crcColumnWidth     = 10
+)
+
+var (
+	docStyle    = lipgloss.NewStyle().Margin(1, 2)
+	titleStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#25A065")).Padding(0, 1)
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8")).Bold(true)
+	matchStyle  = lipgloss.NewStyle().Underline(true)
+	columnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render
+)
+
+type model struct {
+	conf           config.Config
+	archivePaths   []string
+	convertOptions lib.ConvertOptions
+
+	sub      chan indexProgressMsg
+	runId    int64
+	spinner  spinner.Model
+	indexing bool
+	progress indexProgressMsg
+	failures map[string]error
+	err      error
+
+	files   []lib.IndexedFile
+	input   textinput.Model
+	results []list.Rank
+	cursor  int
+	offset  int
+}
+
+func New(conf config.Config, archivePaths []string, convertOptions lib.ConvertOptions) model {
+	input := textinput.New()
+	input.Prompt = "Find: "
+	input.Placeholder = "e.g. TORCHE.O3D"
+	input.Focus()
+
+	return model{
+		conf:           conf,
+		archivePaths:   archivePaths,
+		convertOptions: convertOptions,
+
+		sub:      make(chan indexProgressMsg),
+		runId:    lastRunId.Add(1),
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+		indexing: true,
+		input:    input,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(
+		buildIndex(m.sub, m.runId, m.archivePaths),
+		waitForProgress(m.sub),
+		m.spinner.Tick,
+		textinput.Blink,
+	)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case indexProgressMsg:
+		if msg.runId != m.runId {
+			return m, nil
+		}
+		m.progress = msg
+		return m, waitForProgress(m.sub)
+	case indexReadyMsg:
+		if msg.runId != m.runId {
+			return m, nil
+		}
+		m.indexing = false
+		m.files = msg.files
+		m.failures = msg.failures
+		m.err = msg.err
+		m.search()
+		return m, nil
+	case spinner.TickMsg:
+		if !m.indexing {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.WindowSizeMsg:
+		m.moveCursor(0)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.input.Value() != "" {
+				m.input.SetValue("")
+				m.search()
+				return m, nil
+			}
+			return m, router.Back()
+		case "up", "ctrl+p":
+			m.moveCursor(-1)
+			return m, nil
+		case "down", "ctrl+n":
+			m.moveCursor(1)
+			return m, nil
+		case "pgup":
+			m.moveCursor(-m.pageHeight())
+			return m, nil
+		case "pgdown":
+			m.moveCursor(m.pageHeight())
+			return m, nil
+		case "enter":
+			if m.cursor >= len(m.results) {
+				return m, nil
+			}
+
+			file := m.files[m.results[m.cursor].Index]
+			return m, router.Push(archive_browser.New(m.conf, file.ArchivePath, m.convertOptions).Reveal(file.FileName))
+		}
+	}
+
+	var cmd tea.Cmd
+	previous := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != previous {
+		m.search()
+	}
+	return m, cmd
+}
+
+// an empty query lists everything, otherwise best matches come first
+func (m *model) search() {
+	query := strings.TrimSpace(m.input.Value())
+	if query == "" {
+		m.results = make([]list.Rank, len(m.files))
+		for i := range m.files {
+			m.results[i] = list.Rank{Index: i}
+		}
+	} else {
+		targets := make([]string, len(m.files))
+		for i, file := range m.files {
+			targets[i] = file.FileName
+		}
+		m.results = list.DefaultFilter(query, targets)
+	}
+
+	m.cursor = 0
+	m.offset = 0
+}
+
+func (m *model) moveCursor(delta int) {
+	m.cursor = max(0, min(len(m.results)-1, m.cursor+delta))
+
+	pageHeight := m.pageHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+pageHeight {
+		m.offset = m.cursor - pageHeight + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.results)-pageHeight))
+}
+
+func (m model) pageHeight() int {
+	_, v := docStyle.GetFrameSize()
+	return max(1, filters.GlobalWindowSize.Height-v-headerHeight-footerHeight)
+}
+
+// the query box always has focus
+func (m model) CapturingInput() bool {
+	return true
+}
+
+// indexing keeps going while a result is open in the browser
+func (m model) ReceivesInBackground(msg tea.Msg) bool {
+	switch msg.(type) {
+	case indexProgressMsg, indexReadyMsg, spinner.TickMsg:
+		return true
+	}
+	return false
+}
+
+func (m model) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Find in Archives") + "\n")
+
+	if m.indexing {
+		status := "Indexing archives..."
+		if m.progress.total > 0 {
+			status = fmt.Sprintf("Indexing archives... %d/%d", m.progress.done, m.progress.total)
+		}
+		s.WriteString("\n" + m.spinner.View() + " " + status + "\n")
+		return docStyle.Render(s.String())
+	}
+
+	status := fmt.Sprintf("%d of %d files in %d archives", len(m.results), len(m.files), len(m.archivePaths))
+	if len(m.failures) > 0 {
+		status += errorStyle(fmt.Sprintf(" • %d archives could not be read", len(m.failures)))
+	}
+	if m.err != nil {
+		status += errorStyle(fmt.Sprintf(" • index not cached: %v", m.err))
+	}
+	s.WriteString(status + "\n")
+	s.WriteString(m.input.View() + "\n")
+
+	h, _ := docStyle.GetFrameSize()
+	width := max(40, filters.GlobalWindowSize.Width-h)
+	s.WriteString(columnStyle.Render(columns("Name", "Archive", "Size", "CRC", width)) + "\n")
+
+	pageHeight := m.pageHeight()
+	for i := m.offset; i < min(len(m.results), m.offset+pageHeight); i++ {
+		file := m.files[m.results[i].Index]
+		name := file.FileName
+		if runes := []rune(name); len(runes) > nameColumnWidth(width) {
+			// keep the end, that is the part that tells files apart
+			name = "…" + string(runes[len(runes)-nameColumnWidth(width)+1:])
+		} else {
+			name = highlightMatches(name, m.results[i].MatchedIndexes)
+		}
+		line := columns(name, archiveName(file.ArchivePath), format.Bytes(uint64(file.Size)), fmt.Sprintf("%08x", file.Crc), width)
+		if i == m.cursor {
+			line = cursorStyle.Render(line)
+		}
+		s.WriteString(line + "\n")
+	}
+	for i := len(m.results) - m.offset; i < pageHeight; i++ {
+		s.WriteString("\n")
+	}
+
+	s.WriteString(helpStyle("type to search • ↑/↓ move • enter open in archive • esc back"))
+	return docStyle.Render(s.String())
+}
+
+func nameColumnWidth(width int) int {
+	return max(10, width-archiveColumnWidth-sizeColumnWidth-crcColumnWidth-2)
+}
+
+// name may already be styled so it is only padded, everything else is plain and gets cut to fit
+func columns(name, archive, size, crc string, width int) string {
+	if runes := []rune(archive); len(runes) > archiveColumnWidth {
+		archive = string(runes[:archiveColumnWidth-1]) + "…"
+	}
+
+	return pad(name, nameColumnWidth(width)) + "  " + pad(archive, archiveColumnWidth) +
+		fmt.Sprintf("%*s%*s", sizeColumnWidth, size, crcColumnWidth, crc)
+}
+
+// archives inside an iso keep the image name so the same archive on two discs can be told apart
+func archiveName(archivePath string) string {
+	if isoPath, innerPath, inIso := lib.SplitArchivePath(archivePath); inIso {
+		return filepath.Base(isoPath) + lib.IsoPathSeparator + filepath.Base(innerPath)
+	}
+
+	return filepath.Base(archivePath)
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-lipgloss.Width(s)))
+}
+
+func highlightMatches(s string, matchedIndexes []int) string {
+	if len(matchedIndexes) == 0 {
+		return s
+	}
+
+	matched := make(map[int]bool, len(matchedIndexes))
+	for _, index := range matchedIndexes {
+		matched[index] = true
+	}
+
+	var builder strings.Builder
+	for i, r := range []rune(s) {
+		if matched[i] {
+			builder.WriteString(matchStyle.Render(string(r)))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
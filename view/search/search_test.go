@@ -0,0 +1,32 @@
+package search
+
+import (
+	"stone-tools/config"
+	"stone-tools/lib"
+	"testing"
+)
+
+func TestIgnoresOtherIndexRuns(t *testing.T) {
+	first := New(config.Config{}, nil, lib.ConvertOptions{})
+	second := New(config.Config{}, nil, lib.ConvertOptions{})
+	if first.runId == second.runId {
+		t.Fatalf("both screens got run %d", first.runId)
+	}
+
+	// what the first screen's run sends reaches the second through the router too
+	updated, cmd := second.Update(indexProgressMsg{runId: first.runId, done: 1, total: 2})
+	if cmd != nil || updated.(model).progress.total != 0 {
+		t.Error("the second screen took the first one's progress")
+	}
+
+	files := []lib.IndexedFile{{FileName: "TORCHE.O3D"}}
+	updated, _ = second.Update(indexReadyMsg{runId: first.runId, files: files})
+	if !updated.(model).indexing || len(updated.(model).files) != 0 {
+		t.Error("the second screen took the first one's index")
+	}
+
+	updated, _ = second.Update(indexReadyMsg{runId: second.runId, files: files})
+	if updated.(model).indexing || len(updated.(model).files) != 1 {
+		t.Error("the second screen ignored its own index")
+	}
+}
@@ -0,0 +1,337 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"stone-tools/config"
+	"stone-tools/lib"
+	"stone-tools/view/filters"
+	"stone-tools/view/router"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	docStyle   = lipgloss.NewStyle().Margin(1, 2)
+	titleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFDF5")).Background(lipgloss.Color("#25A065")).Padding(0, 1)
+	labelStyle = lipgloss.NewStyle().Width(24)
+	focusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EE6FF8"))
+	helpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render
+)
+
+// sent to the previous view when leaving after a save so it can pick the changes up
+type SavedMsg struct {
+	Config config.Config
+}
+
+// one editable setting, shown and edited as text
+type field struct {
+	label string
+	hint  string
+	get   func(c config.Config) string
+	set   func(c *config.Config, value string) error
+}
+
+var listSeparator = string(os.PathListSeparator)
+
+var fields = []field{
+	{
+		label: "Darkstone directory",
+		get:   func(c config.Config) string { return c.DarkstoneDirectory },
+		set:   func(c *config.Config, value string) error { c.DarkstoneDirectory = value; return nil },
+	},
+	{
+		label: "Output directory",
+		hint:  "each archive gets its own folder inside",
+		get:   func(c config.Config) string { return c.Extraction.OutputDirectory },
+		set:   func(c *config.Config, value string) error { c.Extraction.OutputDirectory = value; return nil },
+	},
+	{
+		label: "Extraction workers",
+		get:   func(c config.Config) string { return strconv.Itoa(c.Extraction.Workers) },
+		set: func(c *config.Config, value string) error {
+			workers, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("extraction workers must be a number")
+			}
+			c.Extraction.Workers = workers
+			return nil
+		},
+	},
+	{
+		label: "Archives at once",
+		hint:  "when extracting several, 1 does them one after another",
+		get:   func(c config.Config) string { return strconv.Itoa(c.Extraction.Archives) },
+		set: func(c *config.Config, value string) error {
+			archives, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("archives at once must be a number")
+			}
+			c.Extraction.Archives = archives
+			return nil
+		},
+	},
+	{
+		label: "Overwrite policy",
+		hint:  fmt.Sprintf("one of %v", lib.OverwritePolicies),
+		get:   func(c config.Config) string { return string(c.Extraction.Overwrite) },
+		set: func(c *config.Config, value string) error {
+			c.Extraction.Overwrite = lib.OverwritePolicy(strings.ToLower(value))
+			return nil
+		},
+	},
+	{
+		label: "Converters",
+		hint:  "comma separated, any of " + converterNames(),
+		get:   func(c config.Config) string { return strings.Join(c.Extraction.Converters, ",") },
+		set: func(c *config.Config, value string) error {
+			c.Extraction.Converters = splitList(value, ",")
+			return nil
+		},
+	},
+	{
+		label: "Replace raw files",
+		hint:  "true or false",
+		get:   func(c config.Config) string { return strconv.FormatBool(c.Extraction.ReplaceRaw) },
+		set: func(c *config.Config, value string) error {
+			replaceRaw, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("replace raw files must be true or false")
+			}
+			c.Extraction.ReplaceRaw = replaceRaw
+			return nil
+		},
+	},
+	{
+		label: "Mod texture paths",
+		hint:  "separated by " + listSeparator + ", searched before the game's textures",
+		get:   func(c config.Config) string { return strings.Join(c.Textures.ModPaths, listSeparator) },
+		set: func(c *config.Config, value string) error {
+			c.Textures.ModPaths = splitList(value, listSeparator)
+			return nil
+		},
+	},
+	{
+		label: "Texture bank priority",
+		hint:  "comma separated bank directory names, e.g. DRAGONBLADE",
+		get:   func(c config.Config) string { return strings.Join(c.Textures.BankPriority, ",") },
+		set: func(c *config.Config, value string) error {
+			c.Textures.BankPriority = splitList(value, ",")
+			return nil
+		},
+	},
+	{
+		label: "Prefer low res textures",
+		hint:  "true or false",
+		get:   func(c config.Config) string { return strconv.FormatBool(c.Textures.PreferLowRes) },
+		set: func(c *config.Config, value string) error {
+			preferLowRes, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("prefer low res textures must be true or false")
+			}
+			c.Textures.PreferLowRes = preferLowRes
+			return nil
+		},
+	},
+	{
+		label: "Retail archive hashes",
+		hint:  "sha1=release, comma separated, hash untouched archives from your own install or disc (e.g. sha1sum)",
+		get:   func(c config.Config) string { return joinRetailHashes(c.Archives.RetailHashes) },
+		set: func(c *config.Config, value string) error {
+			retailHashes := make(map[string]string)
+			for _, pair := range splitList(value, ",") {
+				hash, release, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("retail archive hashes must look like sha1=release, not `%s`", pair)
+				}
+				retailHashes[strings.ToLower(strings.TrimSpace(hash))] = strings.TrimSpace(release)
+			}
+			c.Archives.RetailHashes = retailHashes
+			return nil
+		},
+	},
+	{
+		label: "Viewer texture paths",
+		hint:  "separated by " + listSeparator + ", relative to where the viewer runs",
+		get:   func(c config.Config) string { return strings.Join(c.Viewer.TexturePaths, listSeparator) },
+		set: func(c *config.Config, value string) error {
+			c.Viewer.TexturePaths = splitList(value, listSeparator)
+			return nil
+		},
+	},
+}
+
+type model struct {
+	conf config.Config
+
+	inputs  []textinput.Model
+	focused int
+	saved   bool
+	status  string
+	err     error
+}
+
+func New(conf config.Config) model {
+	inputs := make([]textinput.Model, len(fields))
+	for i, f := range fields {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = ""
+		inputs[i].CharLimit = 4096
+		inputs[i].SetValue(f.get(conf))
+	}
+	inputs[0].Focus()
+
+	m := model{
+		conf:   conf,
+		inputs: inputs,
+	}
+	m.resizeInputs()
+
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			if m.saved {
+				return m, router.BackWith(SavedMsg{Config: m.conf})
+			}
+			return m, router.Back()
+		case "up", "shift+tab":
+			return m, m.focus(m.focused - 1)
+		case "down", "tab", "enter":
+			return m, m.focus(m.focused + 1)
+		case "ctrl+s":
+			return m.save()
+		}
+	case tea.WindowSizeMsg:
+		m.resizeInputs()
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	return m, cmd
+}
+
+func (m *model) focus(index int) tea.Cmd {
+	m.inputs[m.focused].Blur()
+	m.focused = (index + len(m.inputs)) % len(m.inputs)
+	return m.inputs[m.focused].Focus()
+}
+
+func (m *model) resizeInputs() {
+	h, _ := docStyle.GetFrameSize()
+	width := max(10, filters.GlobalWindowSize.Width-h-labelStyle.GetWidth()-3)
+	for i := range m.inputs {
+		m.inputs[i].Width = width
+	}
+}
+
+// validates everything together before saving so a half applied config never hits the disk
+func (m model) save() (tea.Model, tea.Cmd) {
+	conf := m.conf
+	for i, f := range fields {
+		err := f.set(&conf, strings.TrimSpace(m.inputs[i].Value()))
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+	}
+
+	err := conf.Validate()
+	if err == nil {
+		err = config.SaveConfig(conf)
+	}
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.conf = conf
+	m.saved = true
+	m.err = nil
+	m.status = fmt.Sprintf("Saved profile `%s`", conf.Profile)
+	return m, nil
+}
+
+func (m model) CapturingInput() bool {
+	return true
+}
+
+func (m model) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Settings (%s)", m.conf.Profile)) + "\n\n")
+
+	for i, f := range fields {
+		label := labelStyle.Render(f.label)
+		if i == m.focused {
+			label = focusStyle.Render(labelStyle.Render("> " + f.label))
+		}
+		s.WriteString(label + " " + m.inputs[i].View() + "\n")
+	}
+
+	s.WriteString("\n")
+	if hint := fields[m.focused].hint; hint != "" {
+		s.WriteString(helpStyle(hint) + "\n")
+	}
+	if m.err != nil {
+		s.WriteString(errorStyle(m.err.Error()) + "\n")
+	} else if m.status != "" {
+		s.WriteString(m.status + "\n")
+	}
+	s.WriteString(helpStyle("↑/↓ move • ctrl+s save • esc back") + "\n")
+
+	return docStyle.Render(s.String())
+}
+
+func converterNames() string {
+	names := make([]string, 0, len(lib.Converters))
+	for _, converter := range lib.Converters {
+		names = append(names, converter.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// an empty value is an explicitly empty list rather than a request for the defaults
+// sorted by release so the same releases sit together
+func joinRetailHashes(retailHashes map[string]string) string {
+	pairs := make([]string, 0, len(retailHashes))
+	for hash, release := range retailHashes {
+		pairs = append(pairs, hash+"="+release)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		_, releaseI, _ := strings.Cut(pairs[i], "=")
+		_, releaseJ, _ := strings.Cut(pairs[j], "=")
+		if releaseI != releaseJ {
+			return releaseI < releaseJ
+		}
+		return pairs[i] < pairs[j]
+	})
+
+	return strings.Join(pairs, ",")
+}
+
+func splitList(value, separator string) []string {
+	values := make([]string, 0)
+	for _, part := range strings.Split(value, separator) {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,95 @@
+package view_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// a view counts as settled once nothing has arrived for this long
+	quietPeriod = 150 * time.Millisecond
+	// and gives up waiting after this, a snapshot of a busy view will just not match
+	settleTimeout = 10 * time.Second
+)
+
+// runs a model like tea.Program would, minus the terminal: commands run in goroutines
+// and whatever they return is fed back through Update
+type driver struct {
+	model tea.Model
+	msgs  chan tea.Msg
+	quit  bool
+}
+
+func newDriver(model tea.Model) *driver {
+	d := &driver{model: model, msgs: make(chan tea.Msg, 64)}
+	d.run(model.Init())
+	return d
+}
+
+func (d *driver) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	go func() {
+		d.msgs <- cmd()
+	}()
+}
+
+// sends msg and waits for the view to stop changing
+func (d *driver) send(msg tea.Msg) {
+	d.update(msg)
+	d.settle()
+}
+
+func (d *driver) update(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case nil:
+		return
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			d.run(cmd)
+		}
+		return
+	case tea.QuitMsg:
+		d.quit = true
+		return
+	case spinner.TickMsg, cursor.BlinkMsg:
+		// these tick forever and only animate, dropping them leaves the first frame in place
+		return
+	}
+	if strings.HasSuffix(fmt.Sprintf("%T", msg), "initialBlinkMsg") {
+		return
+	}
+
+	var cmd tea.Cmd
+	d.model, cmd = d.model.Update(msg)
+	d.run(cmd)
+}
+
+func (d *driver) settle() {
+	deadline := time.After(settleTimeout)
+	for {
+		select {
+		case msg := <-d.msgs:
+			d.update(msg)
+		case <-time.After(quietPeriod):
+			return
+		case <-deadline:
+			return
+		}
+	}
+}
+
+func (d *driver) view() string {
+	if d.quit {
+		return "(quit)\n"
+	}
+
+	return d.model.View()
+}
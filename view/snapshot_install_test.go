@@ -0,0 +1,92 @@
+package view_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// every file in the synthetic install carries this time so the picker's date column stays put
+var fixtureTime = time.Date(2001, time.March, 15, 12, 30, 0, 0, time.UTC)
+
+type virtualFile struct {
+	name string // windows separators, like the real archives
+	data []byte
+}
+
+// two small archives standing in for a real install, uncompressed so they are easy to write
+var fixtureArchives = map[string][]virtualFile{
+	"DATA.MTF": {
+		{name: "DATA\\README.TXT", data: []byte("Darkstone snapshot fixture\r\n")},
+		{name: "DATA\\LEVELS\\TOWN.DAT", data: bytes.Repeat([]byte{0x00, 0x01, 0x02, 0x03}, 64)},
+		{name: "DATA\\LEVELS\\DUNGEON.DAT", data: bytes.Repeat([]byte("stone"), 40)},
+	},
+	"MUSIC.MTF": {
+		{name: "MUSIC\\TITLE.TXT", data: []byte("title theme\r\n")},
+	},
+}
+
+// lays out a darkstone install under directory, the archives plus an executable so it looks like the real thing
+func writeInstall(directory string) error {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(directory, 0755)
+	if err != nil {
+		return err
+	}
+
+	for archiveName, files := range fixtureArchives {
+		err = writeMtf(filepath.Join(directory, archiveName), files)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeFixtureFile(filepath.Join(directory, "DARKSTONE.EXE"), []byte("MZ"))
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(directory, fixtureTime, fixtureTime)
+}
+
+// the layout ScanMtfFile reads: a count, then a name, offset and size per file, then the data
+func writeMtf(path string, files []virtualFile) error {
+	var header, data bytes.Buffer
+
+	headerSize := 4
+	for _, file := range files {
+		headerSize += 4 + len(file.name) + 1 + 4 + 4
+	}
+
+	binary.Write(&header, binary.LittleEndian, uint32(len(files)))
+	for _, file := range files {
+		binary.Write(&header, binary.LittleEndian, uint32(len(file.name)+1))
+		header.WriteString(file.name)
+		header.WriteByte(0)
+		binary.Write(&header, binary.LittleEndian, uint32(headerSize+data.Len()))
+		binary.Write(&header, binary.LittleEndian, uint32(len(file.data)))
+		data.Write(file.data)
+	}
+
+	return writeFixtureFile(path, append(header.Bytes(), data.Bytes()...))
+}
+
+// permissions and times are pinned since the file picker shows both
+func writeFixtureFile(path string, content []byte) error {
+	err := os.WriteFile(path, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(path, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(path, fixtureTime, fixtureTime)
+}
@@ -0,0 +1,157 @@
+package view_test
+
+import (
+	"path/filepath"
+	"stone-tools/config"
+	"stone-tools/install"
+	"stone-tools/lib"
+	"stone-tools/view/archive_extractor"
+	"stone-tools/view/archive_picker"
+	"stone-tools/view/root_picker"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// what a scenario starts from, paths are relative to the scenario's own directory
+type environment struct {
+	conf     config.Config
+	installs []install.Install
+}
+
+type scenario struct {
+	name  string
+	model func(env environment) tea.Model
+	steps []tea.Msg // sent in order, the view settles after each one
+}
+
+const (
+	installDirectory = "darkstone"
+	otherDirectory   = "darkstone-gog"
+	outputDirectory  = "extracted"
+)
+
+var scenarios = []scenario{
+	{
+		name: "root_picker_installs",
+		model: func(env environment) tea.Model {
+			return root_picker.New(env.conf, env.installs)
+		},
+	},
+	{
+		name: "root_picker_browse",
+		model: func(env environment) tea.Model {
+			return root_picker.New(env.conf, env.installs[:1])
+		},
+	},
+	{
+		name: "root_picker_not_install",
+		model: func(env environment) tea.Model {
+			env.conf.DarkstoneDirectory = "."
+			return root_picker.New(env.conf, env.installs[:1])
+		},
+	},
+	{
+		name: "root_picker_use_suggestion",
+		model: func(env environment) tea.Model {
+			env.conf.DarkstoneDirectory = "."
+			return root_picker.New(env.conf, env.installs[:1])
+		},
+		steps: keys("u"),
+	},
+	{
+		name: "root_picker_open_folder",
+		model: func(env environment) tea.Model {
+			env.conf.DarkstoneDirectory = "."
+			return root_picker.New(env.conf, env.installs[:1])
+		},
+		steps: keys("enter"),
+	},
+	{
+		name: "root_picker_choose",
+		model: func(env environment) tea.Model {
+			return root_picker.New(env.conf, env.installs)
+		},
+		steps: keys("down", "enter"),
+	},
+	{
+		name: "archive_picker",
+		model: func(env environment) tea.Model {
+			return archive_picker.New(env.conf)
+		},
+	},
+	{
+		name: "archive_picker_sort_size",
+		model: func(env environment) tea.Model {
+			return archive_picker.New(env.conf)
+		},
+		steps: keys("o", "o"),
+	},
+	{
+		name: "archive_picker_select",
+		model: func(env environment) tea.Model {
+			return archive_picker.New(env.conf)
+		},
+		steps: keys(" ", "down", " "),
+	},
+	{
+		name: "archive_picker_retail",
+		model: func(env environment) tea.Model {
+			// the hash the settings would hold for an untouched copy of this archive
+			info, _ := lib.ReadArchiveInfo(filepath.Join(installDirectory, "MUSIC.MTF"))
+			env.conf.Archives.RetailHashes = map[string]string{info.Sha1: "1.0 (test)"}
+			return archive_picker.New(env.conf)
+		},
+		steps: keys("o", "o", "o", "o", "o"),
+	},
+	{
+		name: "archive_picker_help",
+		model: func(env environment) tea.Model {
+			return archive_picker.New(env.conf)
+		},
+		steps: keys("?"),
+	},
+	{
+		name: "archive_extractor",
+		model: func(env environment) tea.Model {
+			return archive_extractor.New(env.conf, filepath.Join(installDirectory, "DATA.MTF"), nil, lib.ConvertOptions{})
+		},
+	},
+	{
+		name: "archive_extractor_log",
+		model: func(env environment) tea.Model {
+			return archive_extractor.New(env.conf, filepath.Join(installDirectory, "DATA.MTF"), nil, lib.ConvertOptions{})
+		},
+		steps: keys("l"),
+	},
+	{
+		name: "archive_extractor_batch",
+		model: func(env environment) tea.Model {
+			archivePaths := []string{filepath.Join(installDirectory, "DATA.MTF"), filepath.Join(installDirectory, "MUSIC.MTF")}
+			return archive_extractor.NewBatch(env.conf, archivePaths, lib.ConvertOptions{})
+		},
+	},
+}
+
+func keys(names ...string) []tea.Msg {
+	msgs := make([]tea.Msg, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "enter":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+		case "esc":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEsc})
+		case "down":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyDown})
+		case "up":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyUp})
+		case "tab":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyTab})
+		case " ":
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+		default:
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)})
+		}
+	}
+
+	return msgs
+}
@@ -0,0 +1,173 @@
+package view_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"stone-tools/config"
+	"stone-tools/install"
+	"stone-tools/view/filters"
+	"stone-tools/view/router"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const (
+	windowWidth  = 100
+	windowHeight = 30
+)
+
+var update = flag.Bool("update", false, "write the current views as the new golden files instead of comparing")
+
+// drives the views headlessly and compares what they show with testdata/<scenario>.golden.
+// the scenarios change the working directory, so none of them run in parallel
+func TestSnapshots(t *testing.T) {
+	goldenPath, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same output on every machine: no colors, no terminal queries, dates in utc
+	lipgloss.SetColorProfile(termenv.Ascii)
+	lipgloss.SetHasDarkBackground(true)
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+	filters.GlobalWindowSize = filters.WindowSize{Width: windowWidth, Height: windowHeight}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			view := runScenario(t, s)
+
+			goldenFile := filepath.Join(goldenPath, s.name+".golden")
+			if *update {
+				err := os.WriteFile(goldenFile, []byte(view), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			golden, err := os.ReadFile(goldenFile)
+			if errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("no golden file, run with -update to create it")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if difference := firstDifference(normalizeLineEndings(string(golden)), view); difference != "" {
+				t.Errorf("view does not match %s\n%s", goldenFile, difference)
+			}
+		})
+	}
+}
+
+// each scenario gets a fresh install in its own directory, worked from relatively so
+// the paths shown do not depend on where the temporary directory ended up
+func runScenario(t *testing.T, s scenario) string {
+	t.Helper()
+
+	root := t.TempDir()
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// registered after TempDir so it runs first and the directory can be removed
+	t.Cleanup(func() { os.Chdir(workingDirectory) })
+
+	// suggested installs are shown as absolute paths, resolved the way the views will see them
+	resolvedRoot, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, directory := range []string{installDirectory, otherDirectory} {
+		err = writeInstall(directory)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the views save settings as they go, keep that away from the real config
+	t.Setenv("STONE_TOOLS_CONFIG", filepath.Join(root, "config.json"))
+	conf, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.DarkstoneDirectory = installDirectory
+	conf.Extraction.OutputDirectory = outputDirectory
+	// one worker so files finish, and get logged, in archive order
+	conf.Extraction.Workers = 1
+	conf.Extraction.Archives = 1
+
+	env := environment{
+		conf: conf,
+		installs: []install.Install{
+			{Path: installDirectory, Source: "registry"},
+			{Path: otherDirectory, Source: "gog"},
+		},
+	}
+
+	d := newDriver(router.New(s.model(env)))
+	d.send(tea.WindowSizeMsg{Width: windowWidth, Height: windowHeight})
+	for _, msg := range s.steps {
+		d.send(msg)
+	}
+
+	return maskVolatile(d.view(), resolvedRoot)
+}
+
+var (
+	clockPattern    = regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}\.\d{3}\b`)
+	ratePattern     = regexp.MustCompile(`\d+(\.\d+)? [KMGT]?i?B/s\b`)
+	durationPattern = regexp.MustCompile(`\b(\d+(\.\d+)?(h|ms|µs|ns|m|s))+\b`)
+	// the file picker's size column, what a directory reports depends on the file system
+	directorySizePattern = regexp.MustCompile(`(d[rwx-]{9} +)\S+`)
+)
+
+// how long things took and where the scenario ran changes every run, the rest of the view should not
+func maskVolatile(view string, root string) string {
+	view = strings.ReplaceAll(view, root, "<root>")
+	view = directorySizePattern.ReplaceAllString(view, "${1}<size>")
+	view = clockPattern.ReplaceAllString(view, "hh:mm:ss.mmm")
+	view = ratePattern.ReplaceAllString(view, "<rate>")
+	return durationPattern.ReplaceAllString(view, "<duration>")
+}
+
+func normalizeLineEndings(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// the first line that differs, empty when both match
+func firstDifference(expected string, actual string) string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var expectedLine, actualLine string
+		if i < len(expectedLines) {
+			expectedLine = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			actualLine = actualLines[i]
+		}
+		if expectedLine != actualLine {
+			return fmt.Sprintf("  line %d\n  - %q\n  + %q\n", i+1, expectedLine, actualLine)
+		}
+	}
+
+	return ""
+}
@@ -2,19 +2,19 @@ package view
 
 import (
 	"os"
-	"path/filepath"
-	"runtime"
 	"stone-tools/config"
+	"stone-tools/install"
 	"stone-tools/view/archive_picker"
 	"stone-tools/view/filters"
 	"stone-tools/view/root_picker"
+	"stone-tools/view/router"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"golang.org/x/sys/windows/registry"
 )
 
-func Run() error {
-	conf, err := config.LoadConfig()
+// an empty profile picks up whichever one was used last
+func Run(profile string) error {
+	conf, err := loadConfig(profile)
 	if err != nil {
 		return err
 	}
@@ -22,19 +22,20 @@ func Run() error {
 	var model tea.Model
 	if conf.DarkstoneDirectory == "" {
 		// need to figure out root darkstone directory
-		startDirectory, err := determineStartDirectory()
+		installs := install.Detect()
+		startDirectory, err := determineStartDirectory(installs)
 		if err != nil {
 			return err
 		}
 
 		conf.DarkstoneDirectory = startDirectory
-		model = root_picker.New(conf)
+		model = root_picker.New(conf, installs)
 	} else {
 		// go straight to archive selector
 		model = archive_picker.New(conf)
 	}
 
-	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithFilter(filters.GlobalFilter))
+	p := tea.NewProgram(router.New(model), tea.WithAltScreen(), tea.WithFilter(filters.GlobalFilter))
 	if _, err := p.Run(); err != nil {
 		return err
 	}
@@ -42,68 +43,23 @@ func Run() error {
 	return nil
 }
 
-var possibleWindowRegKeys = []string{
-	"SOFTWARE\\WOW6432Node\\DelphineSoft\\Darkstone\\CurrentVersion\\Darkstone",
-	"SOFTWARE\\DelphineSoft\\Darkstone\\CurrentVersion\\Darkstone",
-	"SOFTWARE\\WOW6432Node\\Delphine Software\\Darkstone\\CurrentVersion\\Darkstone",
-	"SOFTWARE\\Delphine Software\\Darkstone\\CurrentVersion\\Darkstone",
-	"SOFTWARE\\WOW6432Node\\Delphine Software\\Darkstone",
-	"SOFTWARE\\Delphine Software\\Darkstone",
-}
+func determineStartDirectory(installs []install.Install) (string, error) {
+	if len(installs) > 0 {
+		return installs[0].Path, nil
+	}
 
-var possibleWindowsRegValues = []string{
-	"DataPath",
-	"InstallPath",
+	return os.UserHomeDir()
 }
 
-func determineStartDirectory() (string, error) {
-	userHomeDirectory, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+func loadConfig(profile string) (config.Config, error) {
+	if profile == "" {
+		return config.LoadConfig()
 	}
 
-	if runtime.GOOS == "windows" {
-		keyFound := false
-		var key registry.Key
-		for _, keyPath := range possibleWindowRegKeys {
-			key, err = registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
-			if err != nil {
-				continue
-			}
-
-			keyFound = true
-			break
-		}
-
-		if !keyFound {
-			return userHomeDirectory, nil
-		}
-		defer key.Close()
-
-		// Check if the value exists
-		valueNameFound := false
-		valueName := ""
-		for _, valueName = range possibleWindowsRegValues {
-			_, _, err = key.GetStringValue(valueName)
-			if err != nil {
-				continue
-			}
-
-			valueNameFound = true
-			break
-		}
-		if !valueNameFound {
-			return userHomeDirectory, nil
-		}
-
-		// Get the value's data
-		value, _, err := key.GetStringValue(valueName)
-		if err != nil {
-			return userHomeDirectory, nil
-		}
-
-		return filepath.Clean(value), nil
+	err := config.SetActiveProfile(profile)
+	if err != nil {
+		return config.Config{}, err
 	}
 
-	return userHomeDirectory, nil
+	return config.LoadProfile(profile)
 }